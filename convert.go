@@ -0,0 +1,45 @@
+package main
+
+var convertCommand = _Command{
+	name:        "convert",
+	usage:       "[-from format] [-to format] [-o output] input",
+	description: "Convert a LAMMPS data file into JSON or back.",
+	run:         runConvert,
+}
+
+func runConvert(command *_Command, args []string) error {
+	flags := newFlagSet(command)
+	from := flags.String("from", "", "input format: lammps or json (detected by the extension by default)")
+	to := flags.String("to", "", "output format: lammps or json (by default detected by the extension, otherwise the opposite of the input one)")
+	output := flags.String("o", stdio, "output file")
+	// the flags of the first versions of the tool
+	infile := flags.String("infile", "", "input file, the same as the argument")
+	flags.StringVar(output, "outfile", stdio, "output file, the same as -o")
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	input := *infile
+	switch {
+	case len(files) == 1 && input == "":
+		input = files[0]
+	case len(files) != 0 || input == "":
+		return usageErrorf("expected one input file")
+	}
+
+	fromFormat, err := detectFormat(*from, input, formatLammps)
+	if err != nil {
+		return err
+	}
+	// without other hints the data file is converted into JSON and vice versa
+	toFormat, err := detectFormat(*to, *output, oppositeFormat(fromFormat))
+	if err != nil {
+		return err
+	}
+	lammpsStruct, err := readStruct(input, fromFormat)
+	if err != nil {
+		return err
+	}
+	return writeStruct(lammpsStruct, *output, toFormat)
+}
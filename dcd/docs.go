@@ -0,0 +1,9 @@
+/*
+Package dcd reads and writes DCD binary trajectories as produced by CHARMM, NAMD
+and the LAMMPS "dump dcd" command. Both byte orders are supported, as well as
+files with and without the unit cell block and files with fixed atoms.
+
+Every snapshot is returned as a structs.Frame, so the coordinates and the box can be
+used together with a structs.LammpsStruct loaded from a data file.
+*/
+package dcd
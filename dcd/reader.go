@@ -0,0 +1,310 @@
+package dcd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+const (
+	headerRecordSize = 84
+	titleLength      = 80
+	unitCellSize     = 6 * 8
+)
+
+// Reader reads frames from a DCD file one by one.
+type Reader struct {
+	reader    io.Reader
+	byteOrder binary.ByteOrder
+
+	// FramesCount is the number of frames stated in the header.
+	// LAMMPS leaves it zero while the dump is still being written.
+	FramesCount      int
+	StartTimestep    int
+	TimestepInterval int
+	TimeStep         float64
+	Titles           []string
+	AtomsCount       int
+
+	hasUnitCell  bool
+	hasFourthDim bool
+	freeAtoms    []int
+	fixedCoords  []structs.AtomCoords
+	framesRead   int
+}
+
+/*
+NewReader reads the DCD header from the reader and returns a Reader
+positioned at the first frame.
+
+Params:
+  - reader: a DCD file content
+
+Returns:
+  - Reader: the reader of the frames
+  - error: any error occured
+*/
+func NewReader(reader io.Reader) (*Reader, error) {
+	dcdReader := &Reader{reader: reader}
+	if err := dcdReader.readHeader(); err != nil {
+		return nil, err
+	}
+	return dcdReader, nil
+}
+
+// ByteOrder returns the byte order the file was written in.
+func (dcdReader *Reader) ByteOrder() binary.ByteOrder {
+	return dcdReader.byteOrder
+}
+
+func (dcdReader *Reader) detectByteOrder() error {
+	var marker [4]byte
+	if _, err := io.ReadFull(dcdReader.reader, marker[:]); err != nil {
+		return fmt.Errorf("could not read the DCD header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(marker[:]) == headerRecordSize {
+		dcdReader.byteOrder = binary.LittleEndian
+	} else if binary.BigEndian.Uint32(marker[:]) == headerRecordSize {
+		dcdReader.byteOrder = binary.BigEndian
+	} else {
+		return errors.New("not a DCD file: wrong size of the first record")
+	}
+	return nil
+}
+
+// readRecord reads a Fortran unformatted record and checks its boundary markers.
+func (dcdReader *Reader) readRecord() ([]byte, error) {
+	var size uint32
+	if err := binary.Read(dcdReader.reader, dcdReader.byteOrder, &size); err != nil {
+		return nil, err
+	}
+	return dcdReader.readRecordBody(size)
+}
+
+func (dcdReader *Reader) readRecordBody(size uint32) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(dcdReader.reader, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	var trailer uint32
+	if err := binary.Read(dcdReader.reader, dcdReader.byteOrder, &trailer); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if trailer != size {
+		return nil, fmt.Errorf("corrupted DCD record: leading size %d, trailing size %d", size, trailer)
+	}
+	return data, nil
+}
+
+func (dcdReader *Reader) readHeader() error {
+	if err := dcdReader.detectByteOrder(); err != nil {
+		return err
+	}
+	header, err := dcdReader.readRecordBody(headerRecordSize)
+	if err != nil {
+		return err
+	}
+	if string(header[:4]) != "CORD" {
+		return errors.New("not a DCD file: the CORD signature is missing")
+	}
+	control := make([]int32, 20)
+	if err := binary.Read(bytes.NewReader(header[4:]), dcdReader.byteOrder, control); err != nil {
+		return err
+	}
+	dcdReader.FramesCount = int(control[0])
+	dcdReader.StartTimestep = int(control[1])
+	dcdReader.TimestepInterval = int(control[2])
+	fixedCount := int(control[8])
+
+	isCharmm := control[19] != 0
+	if isCharmm {
+		dcdReader.TimeStep = float64(math.Float32frombits(uint32(control[9])))
+		dcdReader.hasUnitCell = control[10] == 1
+		dcdReader.hasFourthDim = control[11] == 1
+	} else {
+		// X-PLOR files store the time step as a double in place of control[9] and control[10]
+		bits := dcdReader.byteOrder.Uint64(header[4+9*4 : 4+11*4])
+		dcdReader.TimeStep = math.Float64frombits(bits)
+	}
+
+	if err := dcdReader.readTitles(); err != nil {
+		return err
+	}
+
+	atomsRecord, err := dcdReader.readRecord()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if len(atomsRecord) != 4 {
+		return errors.New("wrong size of the atoms count record")
+	}
+	dcdReader.AtomsCount = int(int32(dcdReader.byteOrder.Uint32(atomsRecord)))
+
+	if fixedCount > 0 {
+		return dcdReader.readFreeAtoms(dcdReader.AtomsCount - fixedCount)
+	}
+	return nil
+}
+
+func (dcdReader *Reader) readTitles() error {
+	titles, err := dcdReader.readRecord()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if len(titles) < 4 {
+		return errors.New("wrong size of the titles record")
+	}
+	titlesCount := int(int32(dcdReader.byteOrder.Uint32(titles)))
+	titles = titles[4:]
+	for i := 0; i < titlesCount && len(titles) >= titleLength; i++ {
+		title := strings.TrimRight(string(titles[:titleLength]), " \x00")
+		dcdReader.Titles = append(dcdReader.Titles, title)
+		titles = titles[titleLength:]
+	}
+	return nil
+}
+
+func (dcdReader *Reader) readFreeAtoms(freeCount int) error {
+	record, err := dcdReader.readRecord()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if len(record) != 4*freeCount {
+		return fmt.Errorf("wrong size of the free atoms record: %d instead of %d", len(record), 4*freeCount)
+	}
+	indices := make([]int32, freeCount)
+	if err := binary.Read(bytes.NewReader(record), dcdReader.byteOrder, indices); err != nil {
+		return err
+	}
+	dcdReader.freeAtoms = make([]int, freeCount)
+	for i, index := range indices {
+		if index < 1 || int(index) > dcdReader.AtomsCount {
+			return fmt.Errorf("free atom index %d is out of bounds", index)
+		}
+		dcdReader.freeAtoms[i] = int(index) - 1
+	}
+	return nil
+}
+
+/*
+Next reads the next frame. When there are no frames left it returns io.EOF.
+*/
+func (dcdReader *Reader) Next() (*structs.Frame, error) {
+	frame := &structs.Frame{
+		Timestep: dcdReader.StartTimestep + dcdReader.framesRead*dcdReader.TimestepInterval,
+	}
+	frame.Time = float64(frame.Timestep) * dcdReader.TimeStep
+
+	atEOF := true
+	if dcdReader.hasUnitCell {
+		record, err := dcdReader.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		atEOF = false
+		if len(record) != unitCellSize {
+			return nil, fmt.Errorf("wrong size of the unit cell record: %d", len(record))
+		}
+		var cell [6]float64
+		if err := binary.Read(bytes.NewReader(record), dcdReader.byteOrder, cell[:]); err != nil {
+			return nil, err
+		}
+		setBox(frame, cell)
+	}
+
+	// after the first frame only the free atoms are stored
+	coordsCount := dcdReader.AtomsCount
+	partial := dcdReader.freeAtoms != nil && dcdReader.framesRead > 0
+	if partial {
+		coordsCount = len(dcdReader.freeAtoms)
+	}
+
+	var axes [3][]float32
+	for i := range axes {
+		record, err := dcdReader.readRecord()
+		if err != nil {
+			if atEOF && i == 0 && err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, unexpectedEOF(err)
+		}
+		if len(record) != 4*coordsCount {
+			return nil, fmt.Errorf("wrong size of the coordinates record: %d instead of %d", len(record), 4*coordsCount)
+		}
+		axes[i] = make([]float32, coordsCount)
+		if err := binary.Read(bytes.NewReader(record), dcdReader.byteOrder, axes[i]); err != nil {
+			return nil, err
+		}
+	}
+	if dcdReader.hasFourthDim {
+		if _, err := dcdReader.readRecord(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+
+	frame.Coords = make([]structs.AtomCoords, dcdReader.AtomsCount)
+	if partial {
+		copy(frame.Coords, dcdReader.fixedCoords)
+		for i, atom := range dcdReader.freeAtoms {
+			frame.Coords[atom] = structs.AtomCoords{X: float64(axes[0][i]), Y: float64(axes[1][i]), Z: float64(axes[2][i])}
+		}
+	} else {
+		for i := range frame.Coords {
+			frame.Coords[i] = structs.AtomCoords{X: float64(axes[0][i]), Y: float64(axes[1][i]), Z: float64(axes[2][i])}
+		}
+		if dcdReader.freeAtoms != nil {
+			// the caller may change the frame, so the fixed atoms are kept apart
+			dcdReader.fixedCoords = slices.Clone(frame.Coords)
+		}
+	}
+	dcdReader.framesRead++
+	return frame, nil
+}
+
+// ReadAll reads all the remaining frames.
+func (dcdReader *Reader) ReadAll() ([]*structs.Frame, error) {
+	frames := make([]*structs.Frame, 0, dcdReader.FramesCount)
+	for {
+		frame, err := dcdReader.Next()
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// setBox converts the DCD unit cell (A, gamma, B, beta, alpha, C) into the frame box.
+func setBox(frame *structs.Frame, cell [6]float64) {
+	angles := [3]float64{cell[4], cell[3], cell[1]}
+	// CHARMM writes angles in degrees, NAMD and LAMMPS write their cosines
+	areCosines := true
+	for _, angle := range angles {
+		if angle < -1 || angle > 1 {
+			areCosines = false
+		}
+	}
+	if areCosines {
+		for i := range angles {
+			angles[i] = math.Acos(angles[i]) * 180 / math.Pi
+		}
+	}
+	frame.SpaceDimention, frame.TiltFactors, frame.Triclinic =
+		structs.BoxFromCell([3]float64{cell[0], cell[2], cell[5]}, angles)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+package dcd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+// charmmVersion is the version LAMMPS pretends to be when writing DCD files.
+const charmmVersion = 24
+
+/*
+Writer writes frames in the layout of the LAMMPS "dump dcd" command:
+a CHARMM header with the unit cell flag set and a unit cell block before
+every frame.
+*/
+type Writer struct {
+	writer    io.Writer
+	byteOrder binary.ByteOrder
+
+	// TimestepInterval and TimeStep are stored in the header.
+	// They must be set before the first frame is written.
+	TimestepInterval int
+	TimeStep         float64
+	// Titles are the title lines of the header, cut to 80 characters each.
+	// They must be set before the first frame is written.
+	Titles []string
+
+	atomsCount    int
+	framesCount   int
+	lastTimestep  int
+	headerWritten bool
+	buffer        bytes.Buffer
+}
+
+/*
+NewWriter creates a writer of the frames with the given atoms count.
+
+Params:
+  - writer: the destination; if it is an io.WriteSeeker, the frames count
+    in the header is updated by Close
+  - atomsCount: the number of atoms in every frame
+  - byteOrder: the byte order of the file
+
+Returns:
+  - Writer: the writer of the frames
+*/
+func NewWriter(writer io.Writer, atomsCount int, byteOrder binary.ByteOrder) *Writer {
+	return &Writer{
+		writer:           writer,
+		byteOrder:        byteOrder,
+		TimestepInterval: 1,
+		Titles:           []string{"Created by lammps-file-parser"},
+		atomsCount:       atomsCount,
+	}
+}
+
+func (dcdWriter *Writer) put(data any) {
+	binary.Write(&dcdWriter.buffer, dcdWriter.byteOrder, data)
+}
+
+func (dcdWriter *Writer) flush() error {
+	_, err := dcdWriter.writer.Write(dcdWriter.buffer.Bytes())
+	dcdWriter.buffer.Reset()
+	return err
+}
+
+func (dcdWriter *Writer) writeHeader(startTimestep int) error {
+	dcdWriter.put(int32(headerRecordSize))
+	dcdWriter.buffer.WriteString("CORD")
+	control := make([]int32, 20)
+	control[1] = int32(startTimestep)
+	control[2] = int32(dcdWriter.TimestepInterval)
+	control[3] = int32(startTimestep)
+	control[9] = int32(math.Float32bits(float32(dcdWriter.TimeStep)))
+	control[10] = 1 // has unit cell
+	control[19] = charmmVersion
+	dcdWriter.put(control)
+	dcdWriter.put(int32(headerRecordSize))
+
+	dcdWriter.put(int32(4 + titleLength*len(dcdWriter.Titles)))
+	dcdWriter.put(int32(len(dcdWriter.Titles)))
+	for _, title := range dcdWriter.Titles {
+		dcdWriter.buffer.WriteString(fmt.Sprintf("%-*s", titleLength, title)[:titleLength])
+	}
+	dcdWriter.put(int32(4 + titleLength*len(dcdWriter.Titles)))
+
+	dcdWriter.put(int32(4))
+	dcdWriter.put(int32(dcdWriter.atomsCount))
+	dcdWriter.put(int32(4))
+	return dcdWriter.flush()
+}
+
+// WriteFrame appends the frame to the file.
+func (dcdWriter *Writer) WriteFrame(frame *structs.Frame) error {
+	if len(frame.Coords) != dcdWriter.atomsCount {
+		return fmt.Errorf("the frame has %d atoms, but the file has %d", len(frame.Coords), dcdWriter.atomsCount)
+	}
+	if !dcdWriter.headerWritten {
+		if err := dcdWriter.writeHeader(frame.Timestep); err != nil {
+			return err
+		}
+		dcdWriter.headerWritten = true
+	}
+
+	dcdWriter.put(int32(unitCellSize))
+	dcdWriter.put(unitCell(frame))
+	dcdWriter.put(int32(unitCellSize))
+
+	axis := make([]float32, dcdWriter.atomsCount)
+	for component := 0; component < 3; component++ {
+		for i, coords := range frame.Coords {
+			switch component {
+			case 0:
+				axis[i] = float32(coords.X)
+			case 1:
+				axis[i] = float32(coords.Y)
+			case 2:
+				axis[i] = float32(coords.Z)
+			}
+		}
+		dcdWriter.put(int32(4 * dcdWriter.atomsCount))
+		dcdWriter.put(axis)
+		dcdWriter.put(int32(4 * dcdWriter.atomsCount))
+	}
+	if err := dcdWriter.flush(); err != nil {
+		return err
+	}
+	dcdWriter.framesCount++
+	dcdWriter.lastTimestep = frame.Timestep
+	return nil
+}
+
+/*
+Close finishes the file. If the destination is seekable, the frames count
+and the last timestep are written into the header as LAMMPS does.
+It does not close the destination itself.
+*/
+func (dcdWriter *Writer) Close() error {
+	if !dcdWriter.headerWritten {
+		return errors.New("no frames were written")
+	}
+	seeker, ok := dcdWriter.writer.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+	end, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	// NSET follows the record marker and the CORD signature
+	if _, err := seeker.Seek(8, io.SeekStart); err != nil {
+		return err
+	}
+	dcdWriter.put(int32(dcdWriter.framesCount))
+	if err := dcdWriter.flush(); err != nil {
+		return err
+	}
+	// NSTEP is the fourth control value
+	if _, err := seeker.Seek(20, io.SeekStart); err != nil {
+		return err
+	}
+	dcdWriter.put(int32(dcdWriter.lastTimestep))
+	if err := dcdWriter.flush(); err != nil {
+		return err
+	}
+	_, err = seeker.Seek(end, io.SeekStart)
+	return err
+}
+
+// unitCell returns A, cos(gamma), B, cos(beta), cos(alpha), C like LAMMPS does.
+func unitCell(frame *structs.Frame) [6]float64 {
+	lengths, _ := structs.CellFromBox(frame.SpaceDimention, frame.TiltFactors)
+	cell := [6]float64{lengths[0], 0, lengths[1], 0, 0, lengths[2]}
+	if frame.Triclinic {
+		ly := frame.SpaceDimention[structs.DIMENTION_TYPE_Y][1] - frame.SpaceDimention[structs.DIMENTION_TYPE_Y][0]
+		xy, xz, yz := frame.TiltFactors[0], frame.TiltFactors[1], frame.TiltFactors[2]
+		a, b, c := lengths[0], lengths[1], lengths[2]
+		cell[1] = xy / b
+		cell[3] = xz / c
+		cell[4] = (xy*xz + ly*yz) / (b * c)
+		if a == 0 || b == 0 || c == 0 {
+			cell[1], cell[3], cell[4] = 0, 0, 0
+		}
+	}
+	return cell
+}
@@ -1,5 +1,6 @@
 /*
-Package deserialize contains the function Deserialize
-that converts a set of objects into a LAMMPS file.
+Package deserialize contains the function Deserialize that converts a LAMMPS file
+into a set of objects, and the function DeserializeJSON that does the same
+for the JSON representation of the objects.
 */
 package deserialize
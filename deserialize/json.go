@@ -0,0 +1,62 @@
+package deserialize
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+/*
+DeserializeJSON converts the JSON representation of a LAMMPS file (as produced
+by json.Marshal of a LammpsStruct) back into the set of objects.
+The JSON is validated: unknown fields and wrong value types are rejected, as well as
+a structure that would not make a valid data file, e.g. a bond to a missing atom.
+
+Params:
+  - content: a JSON content
+  - filename: a JSON file name
+
+Returns:
+  - LammpsStruct: the file contents' representation
+  - error: any error occured; validation errors are joined together
+*/
+func DeserializeJSON(content []byte, fileName string) (*structs.LammpsStruct, error) {
+	result, err := DecodeJSON(content, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DecodeJSON is DeserializeJSON that checks only the JSON structure, not the consistency of the objects.
+func DecodeJSON(content []byte, fileName string) (*structs.LammpsStruct, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	result := &structs.LammpsStruct{}
+	if err := decoder.Decode(result); err != nil {
+		return nil, fmt.Errorf("wrong JSON structure: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("wrong JSON structure: unexpected data after the object")
+	}
+	result.FileName = fileName
+	return result, nil
+}
+
+// validate rejects the structures with the errors found by structs.Validate; the warnings are allowed.
+func validate(lammpsStruct *structs.LammpsStruct) error {
+	errs := make([]error, 0)
+	for _, finding := range lammpsStruct.Validate(structs.DefaultValidateOptions(lammpsStruct.Units)) {
+		if finding.Severity == structs.SEVERITY_ERROR {
+			errs = append(errs, errors.New(finding.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+var diffCommand = _Command{
+	name:        "diff",
+	usage:       "[-tolerance value] [-json] [-o output] first second",
+	description: "Compare two data files; exits with 1 if they differ, like diff(1).",
+	run:         runDiff,
+}
+
+func runDiff(command *_Command, args []string) error {
+	flags := newFlagSet(command)
+	tolerance := flags.Float64("tolerance", 1e-6, "largest difference of the coordinates considered equal")
+	asJSON := flags.Bool("json", false, "print JSON instead of text")
+	output := flags.String("o", stdio, "output file")
+	from := flags.String("from", "", "input format: lammps or json (detected by the extension by default)")
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 2 {
+		return usageErrorf("expected two input files")
+	}
+	if files[0] == stdio && files[1] == stdio {
+		return usageErrorf("only one of the files may be the standard input")
+	}
+	first, err := readStruct(files[0], *from)
+	if err != nil {
+		return err
+	}
+	second, err := readStruct(files[1], *from)
+	if err != nil {
+		return err
+	}
+
+	changes := structs.Diff(first, second, *tolerance)
+	err = writeReport(*output, *asJSON, changes, func(writer io.Writer) error {
+		printChanges(writer, files[0], files[1], changes)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(changes) != 0 {
+		return fmt.Errorf("%d differences", len(changes))
+	}
+	return nil
+}
+
+// printChanges prints the changes in the unified diff style with a hunk per section.
+func printChanges(writer io.Writer, firstName, secondName string, changes []structs.Change) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, "--- %s\n+++ %s\n", firstName, secondName)
+	section := ""
+	for _, change := range changes {
+		if change.Section != section {
+			section = change.Section
+			fmt.Fprintf(writer, "@@ %s @@\n", section)
+		}
+		if len(change.Old) != 0 {
+			fmt.Fprintf(writer, "-%s\n", change.Old)
+		}
+		if len(change.New) != 0 {
+			fmt.Fprintf(writer, "+%s\n", change.New)
+		}
+	}
+}
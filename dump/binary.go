@@ -0,0 +1,304 @@
+package dump
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+const (
+	// endianMarker is the integer the newer format stores right after the magic string
+	endianMarker = 0x0001
+	// columnsRevision is the first format revision with the unit style, the time and the columns
+	columnsRevision = 0x0002
+	maxMagicLength  = 64
+)
+
+// BinaryReader reads frames from a binary dump one by one.
+type BinaryReader struct {
+	reader    *bufio.Reader
+	byteOrder binary.ByteOrder
+
+	// Columns names the columns of the old format files which do not store them.
+	// When it is empty the columns of "dump atom" are assumed: "id type xs ys zs" and the image flags.
+	Columns []string
+	// Magic is the magic string of the newer format files, e.g. DUMPCUSTOM, and empty for the old ones.
+	Magic    string
+	Revision int
+
+	units string
+}
+
+/*
+NewBinaryReader returns a BinaryReader positioned at the first snapshot.
+The byte order is detected when the first snapshot is read.
+
+Params:
+  - reader: a binary dump content
+
+Returns:
+  - BinaryReader: the reader of the frames
+*/
+func NewBinaryReader(reader io.Reader) *BinaryReader {
+	return &BinaryReader{reader: bufio.NewReader(reader)}
+}
+
+// ByteOrder returns the byte order the file was written in, or nil before the first frame is read.
+func (binaryReader *BinaryReader) ByteOrder() binary.ByteOrder {
+	return binaryReader.byteOrder
+}
+
+/*
+detectByteOrder checks the first numbers of the file: the newer format starts with
+the negated length of the magic string followed by the endian marker, the old one
+with a non-negative timestep, the number of atoms and the triclinic flag.
+*/
+func (binaryReader *BinaryReader) detectByteOrder() error {
+	start, err := binaryReader.reader.Peek(20)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	for _, byteOrder := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		first := int64(byteOrder.Uint64(start))
+		if first < 0 && first >= -maxMagicLength {
+			magic, err := binaryReader.reader.Peek(8 + int(-first) + 4)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			if byteOrder.Uint32(magic[8-first:]) == endianMarker {
+				binaryReader.byteOrder = byteOrder
+				return nil
+			}
+			continue
+		}
+		atomsCount := int64(byteOrder.Uint64(start[8:]))
+		triclinic := byteOrder.Uint32(start[16:])
+		if first >= 0 && atomsCount >= 0 && atomsCount < 1<<40 && triclinic <= 1 {
+			binaryReader.byteOrder = byteOrder
+			return nil
+		}
+	}
+	return errors.New("not a binary LAMMPS dump: unknown byte order")
+}
+
+/*
+Next reads the next frame. When there are no frames left it returns io.EOF.
+*/
+func (binaryReader *BinaryReader) Next() (*structs.Frame, error) {
+	if _, err := binaryReader.reader.Peek(1); err == io.EOF {
+		return nil, io.EOF
+	}
+	if binaryReader.byteOrder == nil {
+		if err := binaryReader.detectByteOrder(); err != nil {
+			return nil, err
+		}
+	}
+
+	timestep, err := binaryReader.readBigint()
+	if err != nil {
+		return nil, err
+	}
+	if timestep < 0 {
+		if err := binaryReader.readMagic(int(-timestep)); err != nil {
+			return nil, err
+		}
+		if timestep, err = binaryReader.readBigint(); err != nil {
+			return nil, err
+		}
+	}
+	snapshot := &_Snapshot{timestep: int(timestep)}
+	atomsCount, err := binaryReader.readBigint()
+	if err != nil {
+		return nil, err
+	}
+	if err := binaryReader.readBox(snapshot); err != nil {
+		return nil, err
+	}
+	valuesCount, err := binaryReader.readInt()
+	if err != nil {
+		return nil, err
+	}
+	if binaryReader.Magic != "" && binaryReader.Revision >= columnsRevision {
+		if err := binaryReader.readDescription(snapshot); err != nil {
+			return nil, err
+		}
+	} else if snapshot.columns, err = binaryReader.defaultColumns(valuesCount); err != nil {
+		return nil, err
+	}
+	snapshot.units = binaryReader.units
+	if len(snapshot.columns) != valuesCount {
+		return nil, fmt.Errorf("timestep %d: %d columns named for %d values per atom", timestep, len(snapshot.columns), valuesCount)
+	}
+	if err := binaryReader.readAtoms(snapshot, int(atomsCount)); err != nil {
+		return nil, err
+	}
+	return snapshot.frame()
+}
+
+// ReadAll reads all the remaining frames.
+func (binaryReader *BinaryReader) ReadAll() ([]*structs.Frame, error) {
+	return readAll(binaryReader.Next)
+}
+
+func (binaryReader *BinaryReader) readMagic(length int) error {
+	if length > maxMagicLength {
+		return fmt.Errorf("wrong length of the magic string: %d", length)
+	}
+	magic := make([]byte, length)
+	if _, err := io.ReadFull(binaryReader.reader, magic); err != nil {
+		return unexpectedEOF(err)
+	}
+	marker, err := binaryReader.readInt()
+	if err != nil {
+		return err
+	}
+	if marker != endianMarker {
+		return fmt.Errorf("wrong endian marker: %#x", marker)
+	}
+	if binaryReader.Revision, err = binaryReader.readInt(); err != nil {
+		return err
+	}
+	binaryReader.Magic = string(magic)
+	return nil
+}
+
+func (binaryReader *BinaryReader) readBox(snapshot *_Snapshot) error {
+	triclinic, err := binaryReader.readInt()
+	if err != nil {
+		return err
+	}
+	if triclinic > 1 {
+		return fmt.Errorf("unsupported triclinic flag %d: general triclinic boxes are not supported", triclinic)
+	}
+	snapshot.triclinic = triclinic == 1
+	// the boundary styles are not kept
+	var boundary [6]int32
+	if err := binary.Read(binaryReader.reader, binaryReader.byteOrder, &boundary); err != nil {
+		return unexpectedEOF(err)
+	}
+	var bounds [6]float64
+	if err := binary.Read(binaryReader.reader, binaryReader.byteOrder, &bounds); err != nil {
+		return unexpectedEOF(err)
+	}
+	for i := range snapshot.bounds {
+		snapshot.bounds[i] = [2]float64{bounds[2*i], bounds[2*i+1]}
+	}
+	if snapshot.triclinic {
+		if err := binary.Read(binaryReader.reader, binaryReader.byteOrder, &snapshot.tilt); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+	return nil
+}
+
+// readDescription reads the unit style, the time and the column names of the newer format.
+func (binaryReader *BinaryReader) readDescription(snapshot *_Snapshot) error {
+	// the unit style is written with the first snapshot only
+	units, err := binaryReader.readString()
+	if err != nil {
+		return err
+	}
+	if units != "" {
+		binaryReader.units = units
+	}
+	hasTime, err := binaryReader.reader.ReadByte()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	if hasTime != 0 {
+		if err := binary.Read(binaryReader.reader, binaryReader.byteOrder, &snapshot.time); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+	columns, err := binaryReader.readString()
+	if err != nil {
+		return err
+	}
+	snapshot.columns = strings.Fields(columns)
+	return nil
+}
+
+func (binaryReader *BinaryReader) defaultColumns(valuesCount int) ([]string, error) {
+	if len(binaryReader.Columns) > 0 {
+		return binaryReader.Columns, nil
+	}
+	switch valuesCount {
+	case 5:
+		return []string{"id", "type", "xs", "ys", "zs"}, nil
+	case 8:
+		return []string{"id", "type", "xs", "ys", "zs", "ix", "iy", "iz"}, nil
+	}
+	return nil, fmt.Errorf("the file does not name its %d columns, set them in Columns", valuesCount)
+}
+
+// readAtoms reads the chunks of the per-atom values written by every processor.
+func (binaryReader *BinaryReader) readAtoms(snapshot *_Snapshot, atomsCount int) error {
+	valuesCount := len(snapshot.columns)
+	snapshot.values = make([][]float64, valuesCount)
+	for i := range snapshot.values {
+		snapshot.values[i] = make([]float64, 0, atomsCount)
+	}
+	chunksCount, err := binaryReader.readInt()
+	if err != nil {
+		return err
+	}
+	read := 0
+	for chunk := 0; chunk < chunksCount; chunk++ {
+		count, err := binaryReader.readInt()
+		if err != nil {
+			return err
+		}
+		if count < 0 || count%valuesCount != 0 || read+count/valuesCount > atomsCount {
+			return fmt.Errorf("timestep %d: wrong size of the chunk %d: %d", snapshot.timestep, chunk, count)
+		}
+		values := make([]float64, count)
+		if err := binary.Read(binaryReader.reader, binaryReader.byteOrder, values); err != nil {
+			return unexpectedEOF(err)
+		}
+		for i, value := range values {
+			snapshot.values[i%valuesCount] = append(snapshot.values[i%valuesCount], value)
+		}
+		read += count / valuesCount
+	}
+	if read != atomsCount {
+		return fmt.Errorf("timestep %d: %d atoms read instead of %d", snapshot.timestep, read, atomsCount)
+	}
+	return nil
+}
+
+func (binaryReader *BinaryReader) readInt() (int, error) {
+	var value int32
+	if err := binary.Read(binaryReader.reader, binaryReader.byteOrder, &value); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return int(value), nil
+}
+
+func (binaryReader *BinaryReader) readBigint() (int64, error) {
+	var value int64
+	if err := binary.Read(binaryReader.reader, binaryReader.byteOrder, &value); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return value, nil
+}
+
+func (binaryReader *BinaryReader) readString() (string, error) {
+	length, err := binaryReader.readInt()
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || length > math.MaxInt16 {
+		return "", fmt.Errorf("wrong length of a string: %d", length)
+	}
+	text := make([]byte, length)
+	if _, err := io.ReadFull(binaryReader.reader, text); err != nil {
+		return "", unexpectedEOF(err)
+	}
+	return string(text), nil
+}
@@ -0,0 +1,14 @@
+/*
+Package dump reads the trajectories written by the LAMMPS "dump atom" and "dump custom"
+commands, both the text files and the binary ones ("dump_modify binary yes"), so the
+tools/binary2txt converter is not needed. Binary files are accepted in the old format
+and in the newer one with the magic string, the unit style, the time and the column names;
+the byte order is detected from the file itself.
+
+Both readers return the same structs.Frame for the same snapshot. The id, type and element
+columns fill AtomIDs, AtomTypes and Labels, the x/y/z columns (or the unwrapped xu,
+the scaled xs or the scaled unwrapped xsu ones) fill Coords, vx/vy/vz fill Velocities and
+every other column is kept in Properties. When the id column is present the atoms are
+sorted by their IDs, so the i-th coordinate belongs to the i-th atom of a data file.
+*/
+package dump
@@ -0,0 +1,186 @@
+package dump
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+// coordsColumns lists the coordinate columns in the order of preference.
+var coordsColumns = []struct {
+	names  [3]string
+	scaled bool
+}{
+	{[3]string{"x", "y", "z"}, false},
+	{[3]string{"xu", "yu", "zu"}, false},
+	{[3]string{"xs", "ys", "zs"}, true},
+	{[3]string{"xsu", "ysu", "zsu"}, true},
+}
+
+var velocitiesColumns = [3]string{"vx", "vy", "vz"}
+
+// _Snapshot is a snapshot as it is stored in a dump, before it is turned into a frame.
+type _Snapshot struct {
+	timestep  int
+	time      float64
+	units     string
+	triclinic bool
+	// bounds are the bounds of the box as written; for triclinic boxes they bound the whole cell
+	bounds  [3][2]float64
+	tilt    [3]float64
+	columns []string
+	// values holds the values of every column
+	values [][]float64
+	labels []string
+}
+
+func (snapshot *_Snapshot) column(name string) []float64 {
+	for i, column := range snapshot.columns {
+		if column == name {
+			return snapshot.values[i]
+		}
+	}
+	return nil
+}
+
+func (snapshot *_Snapshot) frame() (*structs.Frame, error) {
+	frame := &structs.Frame{
+		Timestep:  snapshot.timestep,
+		Time:      snapshot.time,
+		Units:     snapshot.units,
+		Triclinic: snapshot.triclinic,
+		Labels:    snapshot.labels,
+	}
+	frame.SpaceDimention = snapshot.bounds
+	if snapshot.triclinic {
+		xy, xz, yz := snapshot.tilt[0], snapshot.tilt[1], snapshot.tilt[2]
+		frame.TiltFactors = snapshot.tilt
+		frame.SpaceDimention[0][0] -= min(0, xy, xz, xy+xz)
+		frame.SpaceDimention[0][1] -= max(0, xy, xz, xy+xz)
+		frame.SpaceDimention[1][0] -= min(0, yz)
+		frame.SpaceDimention[1][1] -= max(0, yz)
+	}
+
+	used := make(map[string]bool)
+	if ids := snapshot.column("id"); ids != nil {
+		frame.AtomIDs = roundAll(ids)
+		used["id"] = true
+	}
+	if types := snapshot.column("type"); types != nil {
+		frame.AtomTypes = roundAll(types)
+		used["type"] = true
+	}
+	for _, coords := range coordsColumns {
+		axes, ok := snapshot.vectors(coords.names)
+		if !ok {
+			continue
+		}
+		if coords.scaled {
+			axes = unscale(axes, frame)
+		}
+		frame.Coords = toCoords(axes)
+		for _, name := range coords.names {
+			used[name] = true
+		}
+		break
+	}
+	if axes, ok := snapshot.vectors(velocitiesColumns); ok {
+		frame.Velocities = toCoords(axes)
+		for _, name := range velocitiesColumns {
+			used[name] = true
+		}
+	}
+	for i, column := range snapshot.columns {
+		if used[column] || snapshot.values[i] == nil {
+			continue
+		}
+		if frame.Properties == nil {
+			frame.Properties = make(map[string][]float64)
+		}
+		if _, ok := frame.Properties[column]; ok {
+			return nil, fmt.Errorf("the column %s is repeated", column)
+		}
+		frame.Properties[column] = snapshot.values[i]
+	}
+
+	if frame.AtomIDs != nil {
+		sortByIDs(frame)
+	}
+	return frame, nil
+}
+
+func (snapshot *_Snapshot) vectors(names [3]string) ([3][]float64, bool) {
+	var axes [3][]float64
+	for i, name := range names {
+		if axes[i] = snapshot.column(name); axes[i] == nil {
+			return axes, false
+		}
+	}
+	return axes, true
+}
+
+// unscale turns the fractional coordinates into the cartesian ones within the box of the frame.
+func unscale(axes [3][]float64, frame *structs.Frame) [3][]float64 {
+	lo := [3]float64{frame.SpaceDimention[0][0], frame.SpaceDimention[1][0], frame.SpaceDimention[2][0]}
+	var lengths [3]float64
+	for i := range lengths {
+		lengths[i] = frame.SpaceDimention[i][1] - frame.SpaceDimention[i][0]
+	}
+	xy, xz, yz := frame.TiltFactors[0], frame.TiltFactors[1], frame.TiltFactors[2]
+	x, y, z := make([]float64, len(axes[0])), make([]float64, len(axes[0])), make([]float64, len(axes[0]))
+	for i := range x {
+		xs, ys, zs := axes[0][i], axes[1][i], axes[2][i]
+		x[i] = lo[0] + xs*lengths[0] + ys*xy + zs*xz
+		y[i] = lo[1] + ys*lengths[1] + zs*yz
+		z[i] = lo[2] + zs*lengths[2]
+	}
+	return [3][]float64{x, y, z}
+}
+
+func toCoords(axes [3][]float64) []structs.AtomCoords {
+	coords := make([]structs.AtomCoords, len(axes[0]))
+	for i := range coords {
+		coords[i] = structs.AtomCoords{X: axes[0][i], Y: axes[1][i], Z: axes[2][i]}
+	}
+	return coords
+}
+
+func roundAll(values []float64) []int {
+	numbers := make([]int, len(values))
+	for i, value := range values {
+		numbers[i] = int(math.Round(value))
+	}
+	return numbers
+}
+
+// sortByIDs reorders every per-atom slice of the frame by the atom IDs.
+func sortByIDs(frame *structs.Frame) {
+	order := make([]int, len(frame.AtomIDs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return frame.AtomIDs[order[i]] < frame.AtomIDs[order[j]]
+	})
+	frame.AtomIDs = reorder(frame.AtomIDs, order)
+	frame.AtomTypes = reorder(frame.AtomTypes, order)
+	frame.Labels = reorder(frame.Labels, order)
+	frame.Coords = reorder(frame.Coords, order)
+	frame.Velocities = reorder(frame.Velocities, order)
+	for name, values := range frame.Properties {
+		frame.Properties[name] = reorder(values, order)
+	}
+}
+
+func reorder[T any](values []T, order []int) []T {
+	if values == nil {
+		return nil
+	}
+	sorted := make([]T, len(values))
+	for i, index := range order {
+		sorted[i] = values[index]
+	}
+	return sorted
+}
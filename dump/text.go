@@ -0,0 +1,213 @@
+package dump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+const itemPrefix = "ITEM:"
+
+// TextReader reads frames from a text dump one by one.
+type TextReader struct {
+	scanner    *bufio.Scanner
+	lineNumber int
+	units      string
+}
+
+/*
+NewTextReader returns a TextReader positioned at the first snapshot.
+
+Params:
+  - reader: a text dump content
+
+Returns:
+  - TextReader: the reader of the frames
+*/
+func NewTextReader(reader io.Reader) *TextReader {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return &TextReader{scanner: scanner}
+}
+
+/*
+Next reads the next frame. When there are no frames left it returns io.EOF.
+*/
+func (textReader *TextReader) Next() (*structs.Frame, error) {
+	snapshot := &_Snapshot{units: textReader.units}
+	atomsCount := -1
+	started := false
+	for {
+		line, err := textReader.readLine()
+		if err == io.EOF && !started {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if strings.TrimSpace(line) == "" && !started {
+			continue
+		}
+		started = true
+		if !strings.HasPrefix(line, itemPrefix) {
+			return nil, fmt.Errorf("line %d: expected an ITEM line, got %q", textReader.lineNumber, line)
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(line, itemPrefix))
+		switch {
+		case item == "UNITS":
+			if textReader.units, err = textReader.readLine(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			textReader.units = strings.TrimSpace(textReader.units)
+			snapshot.units = textReader.units
+		case item == "TIME":
+			values, err := textReader.readNumbers(1)
+			if err != nil {
+				return nil, err
+			}
+			snapshot.time = values[0]
+		case item == "TIMESTEP":
+			line, err := textReader.readLine()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			if snapshot.timestep, err = strconv.Atoi(strings.TrimSpace(line)); err != nil {
+				return nil, fmt.Errorf("line %d: wrong timestep: %w", textReader.lineNumber, err)
+			}
+		case item == "NUMBER OF ATOMS":
+			line, err := textReader.readLine()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			if atomsCount, err = strconv.Atoi(strings.TrimSpace(line)); err != nil {
+				return nil, fmt.Errorf("line %d: wrong number of atoms: %w", textReader.lineNumber, err)
+			}
+		case strings.HasPrefix(item, "BOX BOUNDS"):
+			if err := textReader.readBox(snapshot, strings.Fields(item)[2:]); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(item, "ATOMS"):
+			if atomsCount < 0 {
+				return nil, fmt.Errorf("line %d: the atoms come before their number", textReader.lineNumber)
+			}
+			snapshot.columns = strings.Fields(item)[1:]
+			if err := textReader.readAtoms(snapshot, atomsCount); err != nil {
+				return nil, err
+			}
+			return snapshot.frame()
+		default:
+			return nil, fmt.Errorf("line %d: unknown item %q", textReader.lineNumber, item)
+		}
+	}
+}
+
+// ReadAll reads all the remaining frames.
+func (textReader *TextReader) ReadAll() ([]*structs.Frame, error) {
+	return readAll(textReader.Next)
+}
+
+func (textReader *TextReader) readLine() (string, error) {
+	if !textReader.scanner.Scan() {
+		if err := textReader.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	textReader.lineNumber++
+	return textReader.scanner.Text(), nil
+}
+
+func (textReader *TextReader) readNumbers(count int) ([]float64, error) {
+	line, err := textReader.readLine()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	parts := strings.Fields(line)
+	if len(parts) < count {
+		return nil, fmt.Errorf("line %d: expected %d numbers, got %q", textReader.lineNumber, count, line)
+	}
+	numbers := make([]float64, count)
+	for i := range numbers {
+		if numbers[i], err = strconv.ParseFloat(parts[i], 64); err != nil {
+			return nil, fmt.Errorf("line %d: %w", textReader.lineNumber, err)
+		}
+	}
+	return numbers, nil
+}
+
+// readBox reads the three lines of the bounds; triclinic boxes are marked by "xy xz yz".
+func (textReader *TextReader) readBox(snapshot *_Snapshot, flags []string) error {
+	snapshot.triclinic = len(flags) >= 3 && flags[0] == "xy"
+	count := 2
+	if snapshot.triclinic {
+		count = 3
+	}
+	for i := range snapshot.bounds {
+		numbers, err := textReader.readNumbers(count)
+		if err != nil {
+			return err
+		}
+		snapshot.bounds[i] = [2]float64{numbers[0], numbers[1]}
+		if snapshot.triclinic {
+			snapshot.tilt[i] = numbers[2]
+		}
+	}
+	return nil
+}
+
+// readAtoms reads the atom lines; the element column is the only one allowed to hold text.
+func (textReader *TextReader) readAtoms(snapshot *_Snapshot, atomsCount int) error {
+	snapshot.values = make([][]float64, len(snapshot.columns))
+	for i, column := range snapshot.columns {
+		if column == "element" {
+			snapshot.labels = make([]string, atomsCount)
+		} else {
+			snapshot.values[i] = make([]float64, atomsCount)
+		}
+	}
+	for atom := 0; atom < atomsCount; atom++ {
+		line, err := textReader.readLine()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		parts := strings.Fields(line)
+		if len(parts) != len(snapshot.columns) {
+			return fmt.Errorf("line %d: expected %d columns, got %d", textReader.lineNumber, len(snapshot.columns), len(parts))
+		}
+		for i, part := range parts {
+			if snapshot.values[i] == nil {
+				snapshot.labels[atom] = part
+				continue
+			}
+			if snapshot.values[i][atom], err = strconv.ParseFloat(part, 64); err != nil {
+				return fmt.Errorf("line %d: column %s: %w", textReader.lineNumber, snapshot.columns[i], err)
+			}
+		}
+	}
+	return nil
+}
+
+func readAll(next func() (*structs.Frame, error)) ([]*structs.Frame, error) {
+	frames := make([]*structs.Frame, 0)
+	for {
+		frame, err := next()
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
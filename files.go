@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Ivanestver/lammps-file-parser/dcd"
+	"github.com/Ivanestver/lammps-file-parser/deserialize"
+	"github.com/Ivanestver/lammps-file-parser/dump"
+	"github.com/Ivanestver/lammps-file-parser/gromacs"
+	"github.com/Ivanestver/lammps-file-parser/netcdf"
+	"github.com/Ivanestver/lammps-file-parser/serialize"
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+const (
+	formatLammps = "lammps"
+	formatJSON   = "json"
+	// stdio is the file name of the standard input or output
+	stdio = "-"
+)
+
+// the trajectory formats
+const (
+	formatDump       = "dump"
+	formatDumpBinary = "dump-binary"
+	formatDCD        = "dcd"
+	formatXTC        = "xtc"
+	formatTRR        = "trr"
+	formatNetCDF     = "netcdf"
+)
+
+// trajectoryExtensions map the file extensions to the trajectory formats.
+var trajectoryExtensions = map[string]string{
+	".dump":       formatDump,
+	".lammpstrj":  formatDump,
+	".lammpsdump": formatDump,
+	".bin":        formatDumpBinary,
+	".dcd":        formatDCD,
+	".xtc":        formatXTC,
+	".trr":        formatTRR,
+	".nc":         formatNetCDF,
+	".ncdf":       formatNetCDF,
+}
+
+/*
+detectFormat returns the format given by the flag, otherwise the one
+implied by the file extension: .json files are JSON, .data, .lmp and .lammps
+files are data files and the others (and the standard streams) get the fallback.
+*/
+func detectFormat(flagValue, filename, fallback string) (string, error) {
+	switch strings.ToLower(flagValue) {
+	case formatLammps, formatJSON:
+		return strings.ToLower(flagValue), nil
+	case "":
+	default:
+		return "", usageErrorf("unknown format %q, expected %s or %s", flagValue, formatLammps, formatJSON)
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return formatJSON, nil
+	case ".data", ".lmp", ".lammps":
+		return formatLammps, nil
+	}
+	return fallback, nil
+}
+
+func oppositeFormat(format string) string {
+	if format == formatJSON {
+		return formatLammps
+	}
+	return formatJSON
+}
+
+func readFile(filename string) ([]byte, error) {
+	if filename == stdio {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filename)
+}
+
+func writeFile(content []byte, filename string) error {
+	if filename == stdio {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+	return os.WriteFile(filename, content, 0o644)
+}
+
+// readStruct reads a data file or its JSON representation; format may be empty to detect it.
+func readStruct(filename, format string) (*structs.LammpsStruct, error) {
+	format, err := detectFormat(format, filename, formatLammps)
+	if err != nil {
+		return nil, err
+	}
+	content, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var lammpsStruct *structs.LammpsStruct
+	if format == formatJSON {
+		lammpsStruct, err = deserialize.DeserializeJSON(content, filename)
+	} else {
+		lammpsStruct, err = deserialize.Deserialize(string(content), filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return lammpsStruct, nil
+}
+
+// writeStruct writes a data file or its JSON representation; format may be empty to detect it.
+func writeStruct(lammpsStruct *structs.LammpsStruct, filename, format string) error {
+	format, err := detectFormat(format, filename, formatLammps)
+	if err != nil {
+		return err
+	}
+	var content []byte
+	if format == formatJSON {
+		content, err = json.Marshal(lammpsStruct)
+	} else {
+		var text string
+		text, err = serialize.Serialize(lammpsStruct)
+		content = []byte(text)
+	}
+	if err != nil {
+		return err
+	}
+	return writeFile(content, filename)
+}
+
+// writeReport writes a command result as indented JSON or as the text produced by the text function.
+func writeReport(filename string, asJSON bool, report any, text func(io.Writer) error) error {
+	if asJSON {
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFile(append(content, '\n'), filename)
+	}
+	var builder strings.Builder
+	if err := text(&builder); err != nil {
+		return err
+	}
+	return writeFile([]byte(builder.String()), filename)
+}
+
+/*
+trajectoryFormat returns the trajectory format given by the flag, otherwise the one implied
+by the file extension, or an empty string for a data file.
+*/
+func trajectoryFormat(flagValue, filename string) (string, error) {
+	switch format := strings.ToLower(flagValue); format {
+	case formatDump, formatDumpBinary, formatDCD, formatXTC, formatTRR, formatNetCDF:
+		return format, nil
+	case "":
+		return trajectoryExtensions[strings.ToLower(filepath.Ext(filename))], nil
+	default:
+		return "", usageErrorf("unknown trajectory format %q, expected %s, %s, %s, %s, %s or %s", flagValue,
+			formatDump, formatDumpBinary, formatDCD, formatXTC, formatTRR, formatNetCDF)
+	}
+}
+
+/*
+openFrames opens a trajectory for reading its frames one by one.
+
+Returns:
+  - the function returning the next frame or io.EOF after the last one
+  - the function closing the file
+  - error: the file cannot be opened or has a wrong header
+*/
+func openFrames(filename, format string) (func() (*structs.Frame, error), func() error, error) {
+	file := os.Stdin
+	if filename != stdio {
+		var err error
+		if file, err = os.Open(filename); err != nil {
+			return nil, nil, err
+		}
+	} else if format == formatNetCDF {
+		return nil, nil, usageErrorf("a NetCDF file cannot be read from the standard input")
+	}
+	closeFile := func() error {
+		if file == os.Stdin {
+			return nil
+		}
+		return file.Close()
+	}
+
+	var next func() (*structs.Frame, error)
+	switch format {
+	case formatDump:
+		next = dump.NewTextReader(file).Next
+	case formatDumpBinary:
+		next = dump.NewBinaryReader(file).Next
+	case formatXTC:
+		next = gromacs.NewXTCReader(file).Next
+	case formatTRR:
+		next = gromacs.NewTRRReader(file).Next
+	case formatDCD:
+		reader, err := dcd.NewReader(file)
+		if err != nil {
+			closeFile()
+			return nil, nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		next = reader.Next
+	case formatNetCDF:
+		reader, err := netcdf.NewAmberReader(file)
+		if err != nil {
+			closeFile()
+			return nil, nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		index := 0
+		next = func() (*structs.Frame, error) {
+			if index >= reader.FramesCount() {
+				return nil, io.EOF
+			}
+			index++
+			return reader.Frame(index - 1)
+		}
+	}
+	return next, closeFile, nil
+}
@@ -0,0 +1,12 @@
+/*
+Package gromacs reads the trajectories written by GROMACS: the compressed XTC
+format and the full precision TRR format. It is a pure Go port of the xdrfile
+library, so no GROMACS installation is needed.
+
+Frames are returned as structs.Frame. GROMACS stores lengths in nanometres and times
+in picoseconds; the readers scale coordinates, velocities and boxes by LengthScale, which
+is NanometersToAngstroms by default, and keep the times. So the coordinates and boxes are
+in Å, as in the LAMMPS real and metal units, while the velocities in Å/ps and the frame
+times in ps match the metal units only; the real units take Å/fs and fs.
+*/
+package gromacs
@@ -0,0 +1,196 @@
+package gromacs
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+const (
+	trrMagic   = 1993
+	trrVersion = "GMX_trn_file"
+)
+
+// _TRRHeader holds the sizes of the blocks of a TRR frame in bytes.
+type _TRRHeader struct {
+	irSize, eSize, boxSize, virSize, presSize int
+	topSize, symSize, xSize, vSize, fSize     int
+	atomsCount, step                          int
+	isDouble                                  bool
+	time                                      float64
+}
+
+// TRRReader reads frames from a TRR file one by one.
+type TRRReader struct {
+	xdr *_XDRReader
+
+	// LengthScale multiplies coordinates, velocities and box lengths; the time of the
+	// velocities stays in picoseconds.
+	LengthScale float64
+	// Forces of the last read frame, if the file stores them.
+	Forces []structs.AtomCoords
+}
+
+/*
+NewTRRReader creates a reader of the TRR frames.
+
+Params:
+  - reader: a TRR file content
+
+Returns:
+  - TRRReader: the reader of the frames
+*/
+func NewTRRReader(reader io.Reader) *TRRReader {
+	return &TRRReader{
+		xdr:         _NewXDRReader(reader),
+		LengthScale: NanometersToAngstroms,
+	}
+}
+
+func (trrReader *TRRReader) readHeader() (*_TRRHeader, error) {
+	xdr := trrReader.xdr
+	magic, err := xdr.readInt()
+	if err != nil {
+		return nil, err
+	}
+	if magic != trrMagic {
+		return nil, fmt.Errorf("wrong TRR magic number: %d", magic)
+	}
+	if _, err := xdr.readInt(); err != nil {
+		return nil, err
+	}
+	version, err := xdr.readString()
+	if err != nil {
+		return nil, err
+	}
+	if version != trrVersion {
+		return nil, fmt.Errorf("wrong TRR version string: %q", version)
+	}
+	sizes, err := xdr.readInts(13)
+	if err != nil {
+		return nil, err
+	}
+	header := &_TRRHeader{
+		irSize:     int(sizes[0]),
+		eSize:      int(sizes[1]),
+		boxSize:    int(sizes[2]),
+		virSize:    int(sizes[3]),
+		presSize:   int(sizes[4]),
+		topSize:    int(sizes[5]),
+		symSize:    int(sizes[6]),
+		xSize:      int(sizes[7]),
+		vSize:      int(sizes[8]),
+		fSize:      int(sizes[9]),
+		atomsCount: int(sizes[10]),
+		step:       int(sizes[11]),
+	}
+	vectorSizes := []int{header.xSize, header.vSize, header.fSize}
+	hasVectors := header.xSize != 0 || header.vSize != 0 || header.fSize != 0
+	if hasVectors && header.atomsCount <= 0 {
+		return nil, fmt.Errorf("wrong TRR atoms count %d for a frame with coordinates, velocities or forces", header.atomsCount)
+	}
+	// the precision is deduced from the size of any stored block
+	realSize := 0
+	if header.boxSize != 0 {
+		realSize = header.boxSize / 9
+	} else {
+		for _, size := range vectorSizes {
+			if size != 0 {
+				realSize = size / (3 * header.atomsCount)
+				break
+			}
+		}
+	}
+	if realSize != 4 && realSize != 8 {
+		return nil, fmt.Errorf("could not determine the TRR precision")
+	}
+	header.isDouble = realSize == 8
+	// every stored block must hold a vector per atom, so a broken header does not make huge allocations
+	for _, size := range vectorSizes {
+		if size != 0 && size != 3*header.atomsCount*realSize {
+			return nil, fmt.Errorf("the TRR block of %d bytes does not fit %d atoms", size, header.atomsCount)
+		}
+	}
+
+	// the last header integer is the number of energies, the lambda follows the time
+	if header.time, err = xdr.readReal(header.isDouble); err != nil {
+		return nil, err
+	}
+	if _, err := xdr.readReal(header.isDouble); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (trrReader *TRRReader) readVectors(count int, isDouble bool, scale float64) ([]structs.AtomCoords, error) {
+	vectors := make([]structs.AtomCoords, count)
+	for i := range vectors {
+		var values [3]float64
+		for k := range values {
+			value, err := trrReader.xdr.readReal(isDouble)
+			if err != nil {
+				return nil, err
+			}
+			values[k] = value * scale
+		}
+		vectors[i] = structs.AtomCoords{X: values[0], Y: values[1], Z: values[2]}
+	}
+	return vectors, nil
+}
+
+/*
+Next reads the next frame. When there are no frames left it returns io.EOF.
+Coordinates or velocities are nil if the frame does not store them.
+*/
+func (trrReader *TRRReader) Next() (*structs.Frame, error) {
+	if trrReader.xdr.atEOF() {
+		return nil, io.EOF
+	}
+	header, err := trrReader.readHeader()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	frame := &structs.Frame{
+		Timestep: header.step,
+		Time:     header.time,
+	}
+	if err := trrReader.xdr.skip(header.irSize + header.eSize); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if header.boxSize != 0 {
+		var box [9]float64
+		for i := range box {
+			if box[i], err = trrReader.xdr.readReal(header.isDouble); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+		setBox(frame, box, trrReader.LengthScale)
+	}
+	if err := trrReader.xdr.skip(header.virSize + header.presSize + header.topSize + header.symSize); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if header.xSize != 0 {
+		if frame.Coords, err = trrReader.readVectors(header.atomsCount, header.isDouble, trrReader.LengthScale); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	if header.vSize != 0 {
+		if frame.Velocities, err = trrReader.readVectors(header.atomsCount, header.isDouble, trrReader.LengthScale); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	trrReader.Forces = nil
+	if header.fSize != 0 {
+		// forces are kept in the GROMACS units
+		if trrReader.Forces, err = trrReader.readVectors(header.atomsCount, header.isDouble, 1); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	return frame, nil
+}
+
+// ReadAll reads all the remaining frames.
+func (trrReader *TRRReader) ReadAll() ([]*structs.Frame, error) {
+	return readAll(trrReader.Next)
+}
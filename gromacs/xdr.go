@@ -0,0 +1,119 @@
+package gromacs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// NanometersToAngstroms converts GROMACS lengths into LAMMPS real/metal ones.
+const NanometersToAngstroms = 10.0
+
+// _XDRReader reads the big-endian XDR primitives used by the xdrfile library.
+type _XDRReader struct {
+	reader *bufio.Reader
+	buffer [8]byte
+}
+
+func _NewXDRReader(reader io.Reader) *_XDRReader {
+	return &_XDRReader{reader: bufio.NewReader(reader)}
+}
+
+func (xdr *_XDRReader) read(size int) ([]byte, error) {
+	if _, err := io.ReadFull(xdr.reader, xdr.buffer[:size]); err != nil {
+		return nil, err
+	}
+	return xdr.buffer[:size], nil
+}
+
+func (xdr *_XDRReader) readInt() (int32, error) {
+	data, err := xdr.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(data)), nil
+}
+
+func (xdr *_XDRReader) readFloat() (float32, error) {
+	data, err := xdr.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(binary.BigEndian.Uint32(data)), nil
+}
+
+func (xdr *_XDRReader) readDouble() (float64, error) {
+	data, err := xdr.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+}
+
+// readReal reads a float or a double depending on the file precision.
+func (xdr *_XDRReader) readReal(isDouble bool) (float64, error) {
+	if isDouble {
+		return xdr.readDouble()
+	}
+	value, err := xdr.readFloat()
+	return float64(value), err
+}
+
+func (xdr *_XDRReader) readInts(count int) ([]int32, error) {
+	values := make([]int32, count)
+	for i := range values {
+		value, err := xdr.readInt()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// readOpaque reads the given number of bytes and skips the padding up to four bytes.
+func (xdr *_XDRReader) readOpaque(size int) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("negative size of the opaque data: %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(xdr.reader, data); err != nil {
+		return nil, err
+	}
+	if padding := (4 - size%4) % 4; padding > 0 {
+		if _, err := xdr.read(padding); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (xdr *_XDRReader) readString() (string, error) {
+	size, err := xdr.readInt()
+	if err != nil {
+		return "", err
+	}
+	data, err := xdr.readOpaque(int(size))
+	return string(data), err
+}
+
+func (xdr *_XDRReader) skip(size int) error {
+	_, err := xdr.reader.Discard(size)
+	return err
+}
+
+// atEOF reports whether the reader has no more data.
+func (xdr *_XDRReader) atEOF() bool {
+	_, err := xdr.reader.Peek(1)
+	return errors.Is(err, io.EOF)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,375 @@
+package gromacs
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+const xtcMagic = 1995
+
+// magicInts are the sizes of the small-difference ranges of the xdrfile compression.
+var magicInts = [...]int{
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 8, 10, 12, 16, 20, 25, 32, 40, 50, 64,
+	80, 101, 128, 161, 203, 256, 322, 406, 512, 645, 812, 1024, 1290,
+	1625, 2048, 2580, 3250, 4096, 5060, 6501, 8192, 10321, 13003,
+	16384, 20642, 26007, 32768, 41285, 52015, 65536, 82570, 104031,
+	131072, 165140, 208063, 262144, 330280, 416127, 524287, 660561,
+	832255, 1048576, 1321122, 1664510, 2097152, 2642245, 3329021,
+	4194304, 5284491, 6658042, 8388607, 10568983, 13316085, 16777216,
+}
+
+const firstIdx = 9
+
+// XTCReader reads frames from an XTC file one by one.
+type XTCReader struct {
+	xdr *_XDRReader
+
+	// LengthScale multiplies coordinates and box lengths.
+	LengthScale float64
+	// Precision is the compression precision of the last read frame.
+	Precision float64
+}
+
+/*
+NewXTCReader creates a reader of the XTC frames.
+
+Params:
+  - reader: an XTC file content
+
+Returns:
+  - XTCReader: the reader of the frames
+*/
+func NewXTCReader(reader io.Reader) *XTCReader {
+	return &XTCReader{
+		xdr:         _NewXDRReader(reader),
+		LengthScale: NanometersToAngstroms,
+	}
+}
+
+/*
+Next reads the next frame. When there are no frames left it returns io.EOF.
+*/
+func (xtcReader *XTCReader) Next() (*structs.Frame, error) {
+	if xtcReader.xdr.atEOF() {
+		return nil, io.EOF
+	}
+	header, err := xtcReader.xdr.readInts(3)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if header[0] != xtcMagic {
+		return nil, fmt.Errorf("wrong XTC magic number: %d", header[0])
+	}
+	atomsCount := int(header[1])
+	frame := &structs.Frame{Timestep: int(header[2])}
+	time, err := xtcReader.xdr.readFloat()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	frame.Time = float64(time)
+
+	var box [9]float64
+	for i := range box {
+		value, err := xtcReader.xdr.readFloat()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		box[i] = float64(value)
+	}
+	setBox(frame, box, xtcReader.LengthScale)
+
+	coords, err := xtcReader.decompressCoords(atomsCount)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	frame.Coords = make([]structs.AtomCoords, atomsCount)
+	for i := range frame.Coords {
+		frame.Coords[i] = structs.AtomCoords{
+			X: float64(coords[3*i]) * xtcReader.LengthScale,
+			Y: float64(coords[3*i+1]) * xtcReader.LengthScale,
+			Z: float64(coords[3*i+2]) * xtcReader.LengthScale,
+		}
+	}
+	return frame, nil
+}
+
+// ReadAll reads all the remaining frames.
+func (xtcReader *XTCReader) ReadAll() ([]*structs.Frame, error) {
+	return readAll(xtcReader.Next)
+}
+
+func (xtcReader *XTCReader) decompressCoords(atomsCount int) ([]float32, error) {
+	xdr := xtcReader.xdr
+	size, err := xdr.readInt()
+	if err != nil {
+		return nil, err
+	}
+	if int(size) != atomsCount {
+		return nil, fmt.Errorf("wrong atoms count in the XTC coordinates: %d instead of %d", size, atomsCount)
+	}
+	coords := make([]float32, 3*atomsCount)
+
+	// tiny systems are stored uncompressed
+	if atomsCount <= 9 {
+		for i := range coords {
+			if coords[i], err = xdr.readFloat(); err != nil {
+				return nil, err
+			}
+		}
+		xtcReader.Precision = 0
+		return coords, nil
+	}
+
+	precision, err := xdr.readFloat()
+	if err != nil {
+		return nil, err
+	}
+	xtcReader.Precision = float64(precision)
+	bounds, err := xdr.readInts(6)
+	if err != nil {
+		return nil, err
+	}
+	minInt := [3]int{int(bounds[0]), int(bounds[1]), int(bounds[2])}
+	var sizeInt [3]uint
+	for i := range sizeInt {
+		sizeInt[i] = uint(int(bounds[3+i]) - minInt[i] + 1)
+	}
+
+	// check if one of the sizes is too big to be multiplied
+	var bitSizeInt [3]int
+	bitSize := 0
+	if (sizeInt[0] | sizeInt[1] | sizeInt[2]) > 0xffffff {
+		for i := range bitSizeInt {
+			bitSizeInt[i] = sizeOfInt(sizeInt[i])
+		}
+	} else {
+		bitSize = sizeOfInts(sizeInt[:])
+	}
+
+	smallIdxValue, err := xdr.readInt()
+	if err != nil {
+		return nil, err
+	}
+	smallIdx := int(smallIdxValue)
+	if smallIdx < firstIdx || smallIdx >= len(magicInts) {
+		return nil, fmt.Errorf("wrong XTC compression index: %d", smallIdx)
+	}
+	smaller := magicInts[max(firstIdx, smallIdx-1)] / 2
+	smallNum := magicInts[smallIdx] / 2
+	sizeSmall := [3]uint{uint(magicInts[smallIdx]), uint(magicInts[smallIdx]), uint(magicInts[smallIdx])}
+
+	length, err := xdr.readInt()
+	if err != nil {
+		return nil, err
+	}
+	data, err := xdr.readOpaque(int(length))
+	if err != nil {
+		return nil, err
+	}
+	bits := &_BitReader{data: data}
+
+	inversePrecision := 1 / precision
+	var thisCoord, prevCoord [3]int
+	run := 0
+	output := coords[:0]
+	for i := 0; i < atomsCount; {
+		if bitSize == 0 {
+			for k := range thisCoord {
+				thisCoord[k] = int(bits.decodeBits(bitSizeInt[k]))
+			}
+		} else {
+			bits.decodeInts(bitSize, sizeInt[:], thisCoord[:])
+		}
+		i++
+		for k := range thisCoord {
+			thisCoord[k] += minInt[k]
+		}
+		prevCoord = thisCoord
+
+		isSmaller := 0
+		if bits.decodeBits(1) == 1 {
+			run = int(bits.decodeBits(5))
+			isSmaller = run % 3
+			run -= isSmaller
+			isSmaller--
+		}
+		if run > 0 {
+			if i+run/3 > atomsCount {
+				return nil, fmt.Errorf("corrupted XTC frame: too many atoms in the run")
+			}
+			for k := 0; k < run; k += 3 {
+				bits.decodeInts(smallIdx, sizeSmall[:], thisCoord[:])
+				i++
+				for axis := range thisCoord {
+					thisCoord[axis] += prevCoord[axis] - smallNum
+				}
+				if k == 0 {
+					// the first and the second atoms are interchanged
+					// for better compression of water molecules
+					thisCoord, prevCoord = prevCoord, thisCoord
+					output = appendCoord(output, prevCoord, inversePrecision)
+				} else {
+					prevCoord = thisCoord
+				}
+				output = appendCoord(output, thisCoord, inversePrecision)
+			}
+		} else {
+			output = appendCoord(output, thisCoord, inversePrecision)
+		}
+
+		smallIdx += isSmaller
+		if smallIdx < firstIdx || smallIdx >= len(magicInts) {
+			return nil, fmt.Errorf("corrupted XTC frame: compression index %d", smallIdx)
+		}
+		if isSmaller < 0 {
+			smallNum = smaller
+			if smallIdx > firstIdx {
+				smaller = magicInts[smallIdx-1] / 2
+			} else {
+				smaller = 0
+			}
+		} else if isSmaller > 0 {
+			smaller = smallNum
+			smallNum = magicInts[smallIdx] / 2
+		}
+		sizeSmall = [3]uint{uint(magicInts[smallIdx]), uint(magicInts[smallIdx]), uint(magicInts[smallIdx])}
+	}
+	if bits.overflow {
+		return nil, fmt.Errorf("corrupted XTC frame: compressed data is too short")
+	}
+	return coords, nil
+}
+
+func appendCoord(output []float32, coord [3]int, inversePrecision float32) []float32 {
+	return append(output,
+		float32(coord[0])*inversePrecision,
+		float32(coord[1])*inversePrecision,
+		float32(coord[2])*inversePrecision)
+}
+
+// sizeOfInt returns the number of bits needed to store the values up to size.
+func sizeOfInt(size uint) int {
+	bitsCount := 0
+	for number := uint(1); size >= number && bitsCount < 32; number <<= 1 {
+		bitsCount++
+	}
+	return bitsCount
+}
+
+// sizeOfInts returns the number of bits needed to store the product of the sizes.
+func sizeOfInts(sizes []uint) int {
+	var bytes [32]uint
+	bytesCount := 1
+	bytes[0] = 1
+	for _, size := range sizes {
+		var carry uint
+		byteIndex := 0
+		for ; byteIndex < bytesCount; byteIndex++ {
+			carry = bytes[byteIndex]*size + carry
+			bytes[byteIndex] = carry & 0xff
+			carry >>= 8
+		}
+		for carry != 0 {
+			bytes[byteIndex] = carry & 0xff
+			byteIndex++
+			carry >>= 8
+		}
+		bytesCount = byteIndex
+	}
+	bitsCount := 0
+	bytesCount--
+	for number := uint(1); bytes[bytesCount] >= number; number *= 2 {
+		bitsCount++
+	}
+	return bitsCount + bytesCount*8
+}
+
+// _BitReader reads the bit stream of the compressed coordinates.
+type _BitReader struct {
+	data     []byte
+	count    int
+	lastBits uint32
+	lastByte uint32
+	overflow bool
+}
+
+func (bits *_BitReader) nextByte() uint32 {
+	if bits.count >= len(bits.data) {
+		bits.overflow = true
+		return 0
+	}
+	value := uint32(bits.data[bits.count])
+	bits.count++
+	return value
+}
+
+func (bits *_BitReader) decodeBits(bitsCount int) uint32 {
+	mask := uint32((uint64(1) << bitsCount) - 1)
+	var number uint32
+	for bitsCount >= 8 {
+		bits.lastByte = bits.lastByte<<8 | bits.nextByte()
+		number |= (bits.lastByte >> bits.lastBits) << (bitsCount - 8)
+		bitsCount -= 8
+	}
+	if bitsCount > 0 {
+		if int(bits.lastBits) < bitsCount {
+			bits.lastBits += 8
+			bits.lastByte = bits.lastByte<<8 | bits.nextByte()
+		}
+		bits.lastBits -= uint32(bitsCount)
+		number |= (bits.lastByte >> bits.lastBits) & ((1 << bitsCount) - 1)
+	}
+	return number & mask
+}
+
+// decodeInts decodes the numbers packed as a single integer of the given bit size.
+func (bits *_BitReader) decodeInts(bitsCount int, sizes []uint, numbers []int) {
+	var bytes [32]uint
+	bytesCount := 0
+	for bitsCount > 8 {
+		bytes[bytesCount] = uint(bits.decodeBits(8))
+		bytesCount++
+		bitsCount -= 8
+	}
+	if bitsCount > 0 {
+		bytes[bytesCount] = uint(bits.decodeBits(bitsCount))
+		bytesCount++
+	}
+	for i := len(sizes) - 1; i > 0; i-- {
+		var number uint
+		for j := bytesCount - 1; j >= 0; j-- {
+			number = number<<8 | bytes[j]
+			quotient := number / sizes[i]
+			bytes[j] = quotient
+			number -= quotient * sizes[i]
+		}
+		numbers[i] = int(number)
+	}
+	numbers[0] = int(int32(uint32(bytes[0] | bytes[1]<<8 | bytes[2]<<16 | bytes[3]<<24)))
+}
+
+// setBox converts the GROMACS box vectors into the frame box.
+// GROMACS keeps the first vector along x and the second one in the xy plane like LAMMPS does.
+func setBox(frame *structs.Frame, box [9]float64, scale float64) {
+	frame.SpaceDimention[structs.DIMENTION_TYPE_X][1] = box[0] * scale
+	frame.SpaceDimention[structs.DIMENTION_TYPE_Y][1] = box[4] * scale
+	frame.SpaceDimention[structs.DIMENTION_TYPE_Z][1] = box[8] * scale
+	frame.TiltFactors = [3]float64{box[3] * scale, box[6] * scale, box[7] * scale}
+	frame.Triclinic = frame.TiltFactors != [3]float64{}
+}
+
+func readAll(next func() (*structs.Frame, error)) ([]*structs.Frame, error) {
+	frames := make([]*structs.Frame, 0)
+	for {
+		frame, err := next()
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return frames, err
+		}
+		frames = append(frames, frame)
+	}
+}
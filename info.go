@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+var infoCommand = _Command{
+	name:        "info",
+	usage:       "[-units style] [-json] [-o output] input",
+	description: "Print the summary statistics of a data file.",
+	run:         runInfo,
+}
+
+func runInfo(command *_Command, args []string) error {
+	flags := newFlagSet(command)
+	units := flags.String("units", "", "unit style for the density (taken from the file header by default)")
+	asJSON := flags.Bool("json", false, "print JSON instead of text")
+	output := flags.String("o", stdio, "output file")
+	from := flags.String("from", "", "input format: lammps or json (detected by the extension by default)")
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return usageErrorf("expected one input file")
+	}
+	lammpsStruct, err := readStruct(files[0], *from)
+	if err != nil {
+		return err
+	}
+	info := lammpsStruct.Info(*units)
+	return writeReport(*output, *asJSON, info, func(writer io.Writer) error {
+		printInfo(writer, info)
+		return nil
+	})
+}
+
+func printInfo(writer io.Writer, info *structs.Info) {
+	counts := []struct {
+		name    string
+		count   int
+		perType map[int]int
+	}{
+		{"atoms", info.AtomsCount, info.AtomsPerType},
+		{"bonds", info.BondsCount, info.BondsPerType},
+		{"angles", info.AnglesCount, info.AnglesPerType},
+		{"dihedrals", info.DihedralsCount, info.DihedralsPerType},
+		{"impropers", info.ImpropersCount, info.ImpropersPerType},
+	}
+	for _, count := range counts {
+		if count.count == 0 && count.name != "atoms" {
+			continue
+		}
+		fmt.Fprintf(writer, "%-10s %d\n", count.name, count.count)
+		for _, typeNumber := range slices.Sorted(maps.Keys(count.perType)) {
+			fmt.Fprintf(writer, "  type %-4d %d\n", typeNumber, count.perType[typeNumber])
+		}
+	}
+
+	fmt.Fprintln(writer, "composition")
+	for _, label := range slices.Sorted(maps.Keys(info.Composition)) {
+		name := label
+		if len(name) == 0 {
+			name = "(no label)"
+		}
+		fmt.Fprintf(writer, "  %-10s %d\n", name, info.Composition[label])
+	}
+
+	fmt.Fprintf(writer, "total mass   %g\n", info.TotalMass)
+	fmt.Fprintf(writer, "total charge %g\n", info.TotalCharge)
+	fmt.Fprintf(writer, "volume       %g\n", info.Volume)
+	if info.Density != 0 {
+		fmt.Fprintf(writer, "density      %g g/cm^3 (units %s)\n", info.Density, info.Units)
+	} else {
+		fmt.Fprintf(writer, "density      unknown for the units %q\n", info.Units)
+	}
+
+	fmt.Fprintf(writer, "molecules    %d\n", info.MoleculesCount)
+	for _, size := range slices.Sorted(maps.Keys(info.MoleculeSizes)) {
+		fmt.Fprintf(writer, "  %d atoms: %d\n", size, info.MoleculeSizes[size])
+	}
+
+	if len(info.BondLengths) != 0 {
+		fmt.Fprintln(writer, "bond lengths (min mean max)")
+		for _, bondType := range slices.Sorted(maps.Keys(info.BondLengths)) {
+			lengths := info.BondLengths[bondType]
+			fmt.Fprintf(writer, "  type %-4d %g %g %g\n", bondType, lengths.Min, lengths.Mean, lengths.Max)
+		}
+	}
+
+	if info.BondsCount != 0 {
+		fmt.Fprintf(writer, "specials     max 1-2 %d, 1-3 %d, 1-4 %d, all %d per atom\n",
+			info.MaxSpecials[0], info.MaxSpecials[1], info.MaxSpecials[2], info.MaxSpecials[3])
+	}
+
+	fmt.Fprintln(writer, "extents vs box")
+	axes := [3]string{"x", "y", "z"}
+	for i, axis := range axes {
+		fmt.Fprintf(writer, "  %s  atoms %g %g  box %g %g\n", axis,
+			info.Extents[i][0], info.Extents[i][1], info.SpaceDimention[i][0], info.SpaceDimention[i][1])
+	}
+}
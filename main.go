@@ -1,59 +1,126 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+)
 
-	"github.com/Ivanestver/lammps-file-parser/deserialize"
+// Exit codes of the commands.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
 )
 
-func main() {
-	infilePtr := flag.String("infile", "", "input lammps file with data")
-	outfilePtr := flag.String("outfile", "", "output lammps file with data")
-	flag.Parse()
-	if len(*infilePtr) == 0 {
-		fmt.Println("Wrong infile flag usage")
-		return
+// _Command is a subcommand of the CLI.
+type _Command struct {
+	name        string
+	usage       string
+	description string
+	run         func(command *_Command, args []string) error
+}
+
+var commands []_Command
+
+func init() {
+	commands = []_Command{
+		convertCommand,
+		infoCommand,
+		validateCommand,
+		diffCommand,
+		mergeCommand,
+		selectCommand,
+		rdfCommand,
 	}
-	if len(*outfilePtr) == 0 {
-		fmt.Println("Wrong outfile flag usage")
-		return
+}
+
+// _UsageError marks the errors caused by a wrong command line.
+type _UsageError struct {
+	message string
+}
+
+func (err *_UsageError) Error() string {
+	return err.message
+}
+
+func usageErrorf(format string, a ...any) error {
+	return &_UsageError{message: fmt.Sprintf(format, a...)}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return exitUsage
 	}
-	content, err := getFileContent(*infilePtr)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	name, commandArgs := args[0], args[1:]
+	// the old flags-only invocation, e.g. lfp -infile a.data -outfile a.json, is a conversion
+	if len(name) > 1 && name[0] == '-' && name != "-h" && name != "-help" && name != "--help" {
+		name, commandArgs = convertCommand.name, args
 	}
-
-	lammpsStruct, err := deserialize.Deserialize(content, *infilePtr)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	switch name {
+	case "help", "-h", "-help", "--help":
+		printUsage()
+		return exitOK
 	}
-
-	parsedJSON, err := json.Marshal(lammpsStruct)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	for _, command := range commands {
+		if command.name != name {
+			continue
+		}
+		err := command.run(&command, commandArgs)
+		if err == nil || errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintf(os.Stderr, "lfp %s: %s\n", command.name, err.Error())
+		var usageError *_UsageError
+		if errors.As(err, &usageError) {
+			fmt.Fprintf(os.Stderr, "usage: lfp %s %s\n", command.name, command.usage)
+			return exitUsage
+		}
+		return exitFailure
 	}
+	fmt.Fprintf(os.Stderr, "lfp: unknown command %q\n", name)
+	printUsage()
+	return exitUsage
+}
 
-	if err = writeJSON(parsedJSON, *outfilePtr); err == nil {
-		fmt.Println("Done!")
-	} else {
-		fmt.Println(err.Error())
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: lfp <command> [flags] [files]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", command.name, command.description)
 	}
+	fmt.Fprintln(os.Stderr, "\nA file named - is the standard input or output. Run lfp <command> -h for the flags of a command.")
 }
 
-func writeJSON(parsedJSON []byte, outfile string) error {
-	return os.WriteFile(outfile, parsedJSON, os.ModePerm)
+// newFlagSet returns the flags of a command; parsing errors are reported as usage errors.
+func newFlagSet(command *_Command) *flag.FlagSet {
+	flags := flag.NewFlagSet("lfp "+command.name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: lfp %s %s\n\n%s\n\nFlags:\n", command.name, command.usage, command.description)
+		flags.PrintDefaults()
+	}
+	return flags
 }
 
-func getFileContent(filename string) (string, error) {
-	if content, err := os.ReadFile(filename); err == nil {
-		return string(content), nil
-	} else {
-		return "", err
+// parseFlags parses the flags placed anywhere among the files and returns the files.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	files := make([]string, 0)
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &_UsageError{message: err.Error()}
+		}
+		if args = flags.Args(); len(args) == 0 {
+			return files, nil
+		}
+		files, args = append(files, args[0]), args[1:]
 	}
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+var mergeCommand = _Command{
+	name:        "merge",
+	usage:       "[-types offset|number|label] [-o output] input...",
+	description: "Merge several data files into one system.",
+	run:         runMerge,
+}
+
+var typesMergings = map[string]structs.TypesMerging{
+	"offset": structs.TYPES_OFFSET,
+	"number": structs.TYPES_BY_NUMBER,
+	"label":  structs.TYPES_BY_LABEL,
+}
+
+func runMerge(command *_Command, args []string) error {
+	flags := newFlagSet(command)
+	types := flags.String("types", "offset", "how to combine the types: offset (every file gets its own types), "+
+		"number (the files share the type numbers) or label (the atom types with the same label and mass and "+
+		"the other types with the same coefficients are unified)")
+	output := flags.String("o", stdio, "output file")
+	from := flags.String("from", "", "input format: lammps or json (detected by the extension by default)")
+	to := flags.String("to", "", "output format: lammps or json (detected by the extension by default)")
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(files) < 2 {
+		return usageErrorf("expected at least two input files")
+	}
+	typesMerging, ok := typesMergings[*types]
+	if !ok {
+		return usageErrorf("unknown types merging %q", *types)
+	}
+
+	systems := make([]*structs.LammpsStruct, len(files))
+	for i, file := range files {
+		if systems[i], err = readStruct(file, *from); err != nil {
+			return err
+		}
+	}
+	merged, err := structs.Merge(systems, structs.MergeOptions{Types: typesMerging})
+	if err != nil {
+		return err
+	}
+	return writeStruct(merged, *output, *to)
+}
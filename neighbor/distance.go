@@ -0,0 +1,69 @@
+package neighbor
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+/*
+Displacement returns the minimum image vector from one atom to another.
+
+Params:
+  - lammpsStruct: the atoms and the box
+  - from: the ID of the first atom
+  - to: the ID of the second atom
+
+Returns:
+  - AtomCoords: the vector
+  - error: an ID of a missing atom
+*/
+func Displacement(lammpsStruct *structs.LammpsStruct, from, to int) (structs.AtomCoords, error) {
+	first, second := findAtom(lammpsStruct, from), findAtom(lammpsStruct, to)
+	if first == nil {
+		return structs.AtomCoords{}, fmt.Errorf("the atom %d does not exist", from)
+	}
+	if second == nil {
+		return structs.AtomCoords{}, fmt.Errorf("the atom %d does not exist", to)
+	}
+	return lammpsStruct.Displacement(&first.AtomCoords, &second.AtomCoords), nil
+}
+
+/*
+Distance returns the minimum image distance between two atoms.
+
+Params:
+  - lammpsStruct: the atoms and the box
+  - first: the ID of the first atom
+  - second: the ID of the second atom
+
+Returns:
+  - float64: the distance
+  - error: an ID of a missing atom
+*/
+func Distance(lammpsStruct *structs.LammpsStruct, first, second int) (float64, error) {
+	firstAtom, secondAtom := findAtom(lammpsStruct, first), findAtom(lammpsStruct, second)
+	if firstAtom == nil {
+		return 0, fmt.Errorf("the atom %d does not exist", first)
+	}
+	if secondAtom == nil {
+		return 0, fmt.Errorf("the atom %d does not exist", second)
+	}
+	return lammpsStruct.Distance(&firstAtom.AtomCoords, &secondAtom.AtomCoords), nil
+}
+
+// findAtom finds an atom by its ID; the atoms are usually sorted by their IDs.
+func findAtom(lammpsStruct *structs.LammpsStruct, id int) *structs.Atom {
+	atoms := lammpsStruct.Atoms
+	index, found := slices.BinarySearchFunc(atoms, id, func(atom structs.Atom, target int) int { return atom.AtomID - target })
+	if found {
+		return &atoms[index]
+	}
+	for i := range atoms {
+		if atoms[i].AtomID == id {
+			return &atoms[i]
+		}
+	}
+	return nil
+}
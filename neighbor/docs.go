@@ -0,0 +1,15 @@
+/*
+Package neighbor builds the neighbor lists of the atoms of a structs.LammpsStruct: for every
+atom the atoms not farther than a cutoff under the minimum image convention, in an orthogonal
+or a triclinic periodic box. The lists are built by a cell list in a time linear in the number
+of atoms.
+
+A half list keeps every pair once, at the atom with the smaller index, like the LAMMPS
+"neighbor half" lists for pair styles; a full list keeps every pair at both atoms, which is
+handy to count the neighbours of an atom. The atoms are referred to by their indices in the
+Atoms slice, the IDs are available through List.IDs.
+
+The helpers Displacement and Distance give the minimum image vector and distance between
+two atoms by their IDs.
+*/
+package neighbor
@@ -0,0 +1,140 @@
+package neighbor
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+type ListKind = int
+
+const (
+	// HALF_LIST keeps a pair at the atom with the smaller index only.
+	HALF_LIST ListKind = iota
+	// FULL_LIST keeps a pair at both atoms.
+	FULL_LIST
+)
+
+// Options set how a List is built.
+type Options struct {
+	Cutoff float64
+	Kind   ListKind
+}
+
+// Neighbour is an atom of a neighbor list with its distance to the owner of the list.
+type Neighbour struct {
+	Index    int
+	Distance float64
+}
+
+// List holds the neighbours of every atom of a structure, the neighbours of an atom sorted by their indices.
+type List struct {
+	Cutoff     float64
+	Kind       ListKind
+	Neighbours [][]Neighbour
+	ids        []int
+	indices    map[int]int
+}
+
+/*
+Build builds the neighbor list of the atoms of the structure. The list does not follow later
+changes of the structure.
+
+Params:
+  - lammpsStruct: the atoms and the box
+  - options: the cutoff and the kind of the list
+
+Returns:
+  - List: the neighbor list
+  - error: a non-positive cutoff
+*/
+func Build(lammpsStruct *structs.LammpsStruct, options Options) (*List, error) {
+	if options.Cutoff <= 0 {
+		return nil, errors.New("the cutoff must be positive")
+	}
+	list := &List{
+		Cutoff:     options.Cutoff,
+		Kind:       options.Kind,
+		Neighbours: make([][]Neighbour, len(lammpsStruct.Atoms)),
+		ids:        make([]int, len(lammpsStruct.Atoms)),
+		indices:    make(map[int]int, len(lammpsStruct.Atoms)),
+	}
+	for i, atom := range lammpsStruct.Atoms {
+		list.ids[i] = atom.AtomID
+		list.indices[atom.AtomID] = i
+	}
+	lammpsStruct.Pairs(options.Cutoff, func(first, second int, distance float64) {
+		list.Neighbours[first] = append(list.Neighbours[first], Neighbour{Index: second, Distance: distance})
+		if options.Kind == FULL_LIST {
+			list.Neighbours[second] = append(list.Neighbours[second], Neighbour{Index: first, Distance: distance})
+		}
+	})
+	for i := range list.Neighbours {
+		slices.SortFunc(list.Neighbours[i], func(n1, n2 Neighbour) int { return n1.Index - n2.Index })
+	}
+	return list, nil
+}
+
+// IDs returns the atom IDs by the indices of the atoms.
+func (list *List) IDs() []int {
+	return list.ids
+}
+
+// Index returns the index of the atom with the ID, or -1 if there is no such atom.
+func (list *List) Index(id int) int {
+	if index, ok := list.indices[id]; ok {
+		return index
+	}
+	return -1
+}
+
+/*
+Of returns the IDs of the neighbours of an atom. For a half list these are only the
+neighbours with the larger indices.
+
+Params:
+  - id: the ID of the atom
+
+Returns:
+  - []int: the IDs of the neighbours, or nil for a missing atom
+*/
+func (list *List) Of(id int) []int {
+	index, ok := list.indices[id]
+	if !ok {
+		return nil
+	}
+	ids := make([]int, len(list.Neighbours[index]))
+	for i, neighbour := range list.Neighbours[index] {
+		ids[i] = list.ids[neighbour.Index]
+	}
+	return ids
+}
+
+// PairsCount returns the number of the distinct pairs in the list.
+func (list *List) PairsCount() int {
+	count := 0
+	for _, neighbours := range list.Neighbours {
+		count += len(neighbours)
+	}
+	if list.Kind == FULL_LIST {
+		count /= 2
+	}
+	return count
+}
+
+/*
+Pairs calls the visit function once for every distinct pair of the list, whatever its kind.
+
+Params:
+  - visit: gets the indices of the atoms, the first one being smaller, and their distance
+*/
+func (list *List) Pairs(visit func(first, second int, distance float64)) {
+	for first, neighbours := range list.Neighbours {
+		for _, neighbour := range neighbours {
+			if neighbour.Index > first {
+				visit(first, neighbour.Index, neighbour.Distance)
+			}
+		}
+	}
+}
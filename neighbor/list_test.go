@@ -0,0 +1,112 @@
+package neighbor
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+// randomStruct places the atoms uniformly into a cubic box of the given density, tilted when triclinic.
+func randomStruct(atomsCount int, density float64, triclinic bool, seed int64) *structs.LammpsStruct {
+	random := rand.New(rand.NewSource(seed))
+	length := math.Cbrt(float64(atomsCount) / density)
+	lammpsStruct := &structs.LammpsStruct{
+		SpaceDimention: [3][2]float64{{-length / 2, length / 2}, {0, length}, {10, 10 + length}},
+		Atoms:          make([]structs.Atom, atomsCount),
+	}
+	if triclinic {
+		lammpsStruct.Triclinic = true
+		lammpsStruct.TiltFactors = [3]float64{0.3 * length, -0.2 * length, 0.25 * length}
+	}
+	xy, xz, yz := lammpsStruct.TiltFactors[0], lammpsStruct.TiltFactors[1], lammpsStruct.TiltFactors[2]
+	for i := range lammpsStruct.Atoms {
+		a, b, c := random.Float64(), random.Float64(), random.Float64()
+		lammpsStruct.Atoms[i] = structs.Atom{
+			AtomID:   i + 1,
+			AtomType: 1,
+			AtomCoords: structs.AtomCoords{
+				X: -length/2 + a*length + b*xy + c*xz,
+				Y: b*length + c*yz,
+				Z: 10 + c*length,
+			},
+		}
+	}
+	return lammpsStruct
+}
+
+// bruteForceDistance returns the shortest distance between two atoms over the 27 nearest periodic images.
+func bruteForceDistance(lammpsStruct *structs.LammpsStruct, first, second structs.AtomCoords) float64 {
+	var lengths [3]float64
+	for axis, bounds := range lammpsStruct.SpaceDimention {
+		lengths[axis] = bounds[1] - bounds[0]
+	}
+	xy, xz, yz := lammpsStruct.TiltFactors[0], lammpsStruct.TiltFactors[1], lammpsStruct.TiltFactors[2]
+	shortest := math.Inf(1)
+	for i := -1; i <= 1; i++ {
+		for j := -1; j <= 1; j++ {
+			for k := -1; k <= 1; k++ {
+				dx := second.X - first.X + float64(i)*lengths[0] + float64(j)*xy + float64(k)*xz
+				dy := second.Y - first.Y + float64(j)*lengths[1] + float64(k)*yz
+				dz := second.Z - first.Z + float64(k)*lengths[2]
+				shortest = min(shortest, math.Sqrt(dx*dx+dy*dy+dz*dz))
+			}
+		}
+	}
+	return shortest
+}
+
+func TestBuildMatchesBruteForce(t *testing.T) {
+	for _, triclinic := range []bool{false, true} {
+		lammpsStruct := randomStruct(1500, 0.05, triclinic, 1)
+		for _, cutoff := range []float64{1.5, 4, 8} {
+			expected := make(map[[2]int]float64)
+			for i := range lammpsStruct.Atoms {
+				for j := i + 1; j < len(lammpsStruct.Atoms); j++ {
+					if distance := bruteForceDistance(lammpsStruct, lammpsStruct.Atoms[i].AtomCoords, lammpsStruct.Atoms[j].AtomCoords); distance <= cutoff {
+						expected[[2]int{i, j}] = distance
+					}
+				}
+			}
+			for _, kind := range []ListKind{HALF_LIST, FULL_LIST} {
+				list, err := Build(lammpsStruct, Options{Cutoff: cutoff, Kind: kind})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if list.PairsCount() != len(expected) {
+					t.Errorf("triclinic %v, cutoff %g, kind %d: %d pairs, expected %d", triclinic, cutoff, kind, list.PairsCount(), len(expected))
+				}
+				list.Pairs(func(first, second int, distance float64) {
+					expectedDistance, ok := expected[[2]int{first, second}]
+					if !ok || math.Abs(distance-expectedDistance) > 1e-9 {
+						t.Errorf("triclinic %v, cutoff %g, kind %d: unexpected pair %d %d at %g", triclinic, cutoff, kind, first, second, distance)
+					}
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkBuild shows that building a list takes a time linear in the number of atoms at a fixed density.
+func BenchmarkBuild(b *testing.B) {
+	for _, triclinic := range []bool{false, true} {
+		for _, atomsCount := range []int{125_000, 250_000, 500_000, 1_000_000} {
+			name := fmt.Sprintf("orthogonal/%d", atomsCount)
+			if triclinic {
+				name = fmt.Sprintf("triclinic/%d", atomsCount)
+			}
+			b.Run(name, func(b *testing.B) {
+				lammpsStruct := randomStruct(atomsCount, 0.1, triclinic, 1)
+				b.ResetTimer()
+				for b.Loop() {
+					if _, err := Build(lammpsStruct, Options{Cutoff: 3, Kind: HALF_LIST}); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(atomsCount), "ns/atom")
+			})
+		}
+	}
+}
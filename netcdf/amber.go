@@ -0,0 +1,397 @@
+package netcdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+// The names of the dimensions and variables of the AMBER trajectory convention.
+const (
+	dimensionFrame         = "frame"
+	dimensionSpatial       = "spatial"
+	dimensionAtom          = "atom"
+	dimensionCellSpatial   = "cell_spatial"
+	dimensionCellAngular   = "cell_angular"
+	dimensionLabel         = "label"
+	variableCoordinates    = "coordinates"
+	variableVelocities     = "velocities"
+	variableCellLengths    = "cell_lengths"
+	variableCellAngles     = "cell_angles"
+	variableCellOrigin     = "cell_origin"
+	variableTime           = "time"
+	variableStep           = "step"
+	variableSpatial        = "spatial"
+	variableCellSpatial    = "cell_spatial"
+	variableCellAngular    = "cell_angular"
+	attributeScaleFactor   = "scale_factor"
+	attributeConventions   = "Conventions"
+	amberConventions       = "AMBER"
+	amberConventionVersion = "1.0"
+)
+
+// AmberReader reads the frames of a trajectory in the AMBER NetCDF convention.
+type AmberReader struct {
+	*Reader
+	AtomsCount int
+
+	coordinates, velocities *Variable
+	cellLengths, cellAngles *Variable
+	cellOrigin, time, step  *Variable
+	properties              []*Variable
+}
+
+/*
+NewAmberReader opens a trajectory written in the AMBER NetCDF convention,
+for example by the LAMMPS "dump netcdf" command.
+
+Params:
+  - reader: a NetCDF file content
+
+Returns:
+  - AmberReader: the reader of the frames
+  - error: any error occured
+*/
+func NewAmberReader(reader io.ReaderAt) (*AmberReader, error) {
+	file, err := Open(reader)
+	if err != nil {
+		return nil, err
+	}
+	conventions := file.Attribute(attributeConventions)
+	if conventions == nil || !strings.Contains(conventions.String(), amberConventions) {
+		return nil, errors.New("the file does not follow the AMBER convention")
+	}
+	atomDimension := file.Dimension(dimensionAtom)
+	if atomDimension < 0 {
+		return nil, errors.New("the file has no atom dimension")
+	}
+	amber := &AmberReader{
+		Reader:      file,
+		AtomsCount:  file.Dimensions[atomDimension].Length,
+		coordinates: file.Variable(variableCoordinates),
+		velocities:  file.Variable(variableVelocities),
+		cellLengths: file.Variable(variableCellLengths),
+		cellAngles:  file.Variable(variableCellAngles),
+		cellOrigin:  file.Variable(variableCellOrigin),
+		time:        file.Variable(variableTime),
+		step:        file.Variable(variableStep),
+	}
+	// any other per-frame per-atom variable is a custom property
+	for _, variable := range file.Variables {
+		if variable == amber.coordinates || variable == amber.velocities || !variable.isRecord {
+			continue
+		}
+		if len(variable.Dimensions) >= 2 && variable.Dimensions[1] == atomDimension && variable.Type != NC_CHAR {
+			amber.properties = append(amber.properties, variable)
+		}
+	}
+	return amber, nil
+}
+
+// FramesCount returns the number of frames in the file.
+func (amber *AmberReader) FramesCount() int {
+	return amber.RecordsCount
+}
+
+// readScaled reads the record of the variable and applies its scale factor.
+func (amber *AmberReader) readScaled(variable *Variable, record int) ([]float64, error) {
+	values, err := amber.Read(variable, record)
+	if err != nil {
+		return nil, err
+	}
+	if attribute := variable.Attribute(attributeScaleFactor); attribute != nil {
+		if scale, ok := attribute.Float(); ok {
+			for i := range values {
+				values[i] *= scale
+			}
+		}
+	}
+	return values, nil
+}
+
+func (amber *AmberReader) readVectors(variable *Variable, record int) ([]structs.AtomCoords, error) {
+	values, err := amber.readScaled(variable, record)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 3*amber.AtomsCount {
+		return nil, fmt.Errorf("variable %s has %d values instead of %d", variable.Name, len(values), 3*amber.AtomsCount)
+	}
+	vectors := make([]structs.AtomCoords, amber.AtomsCount)
+	for i := range vectors {
+		vectors[i] = structs.AtomCoords{X: values[3*i], Y: values[3*i+1], Z: values[3*i+2]}
+	}
+	return vectors, nil
+}
+
+/*
+Frame reads the frame with the given index. The timestep is taken from the "step"
+variable if the file has one, otherwise it is the frame index.
+*/
+func (amber *AmberReader) Frame(index int) (*structs.Frame, error) {
+	if index < 0 || index >= amber.RecordsCount {
+		return nil, fmt.Errorf("frame %d is out of bounds (frames: %d)", index, amber.RecordsCount)
+	}
+	frame := &structs.Frame{Timestep: index}
+	var err error
+	if amber.step != nil {
+		steps, err := amber.Read(amber.step, index)
+		if err != nil {
+			return nil, err
+		}
+		frame.Timestep = int(steps[0])
+	}
+	if amber.time != nil {
+		times, err := amber.readScaled(amber.time, index)
+		if err != nil {
+			return nil, err
+		}
+		frame.Time = times[0]
+	}
+	if amber.coordinates != nil {
+		if frame.Coords, err = amber.readVectors(amber.coordinates, index); err != nil {
+			return nil, err
+		}
+	}
+	if amber.velocities != nil {
+		if frame.Velocities, err = amber.readVectors(amber.velocities, index); err != nil {
+			return nil, err
+		}
+	}
+	if err := amber.readBox(frame, index); err != nil {
+		return nil, err
+	}
+
+	for _, variable := range amber.properties {
+		values, err := amber.readScaled(variable, index)
+		if err != nil {
+			return nil, err
+		}
+		if frame.Properties == nil {
+			frame.Properties = make(map[string][]float64)
+		}
+		if amber.AtomsCount == 0 || len(values)%amber.AtomsCount != 0 {
+			if len(values) == 0 {
+				frame.Properties[variable.Name] = values
+				continue
+			}
+			return nil, fmt.Errorf("variable %s has %d values, which do not split among %d atoms", variable.Name, len(values), amber.AtomsCount)
+		}
+		components := len(values) / amber.AtomsCount
+		if components == 1 {
+			frame.Properties[variable.Name] = values
+			continue
+		}
+		// vector properties are split like the columns of a LAMMPS dump: name[1], name[2], ...
+		for component := 0; component < components; component++ {
+			column := make([]float64, amber.AtomsCount)
+			for i := range column {
+				column[i] = values[i*components+component]
+			}
+			frame.Properties[fmt.Sprintf("%s[%d]", variable.Name, component+1)] = column
+		}
+	}
+	return frame, nil
+}
+
+func (amber *AmberReader) readBox(frame *structs.Frame, index int) error {
+	if amber.cellLengths == nil {
+		return nil
+	}
+	lengths, err := amber.readScaled(amber.cellLengths, index)
+	if err != nil {
+		return err
+	}
+	angles := []float64{90, 90, 90}
+	if amber.cellAngles != nil {
+		if angles, err = amber.readScaled(amber.cellAngles, index); err != nil {
+			return err
+		}
+	}
+	if len(lengths) != 3 || len(angles) != 3 {
+		return errors.New("wrong size of the cell variables")
+	}
+	frame.SpaceDimention, frame.TiltFactors, frame.Triclinic = structs.BoxFromCell(
+		[3]float64(lengths), [3]float64(angles))
+	if amber.cellOrigin != nil {
+		origin, err := amber.readScaled(amber.cellOrigin, index)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 3 && i < len(origin); i++ {
+			frame.SpaceDimention[i][0] += origin[i]
+			frame.SpaceDimention[i][1] += origin[i]
+		}
+	}
+	return nil
+}
+
+// ReadAll reads all the frames.
+func (amber *AmberReader) ReadAll() ([]*structs.Frame, error) {
+	frames := make([]*structs.Frame, amber.RecordsCount)
+	for i := range frames {
+		frame, err := amber.Frame(i)
+		if err != nil {
+			return nil, err
+		}
+		frames[i] = frame
+	}
+	return frames, nil
+}
+
+// AmberWriter writes frames in the AMBER NetCDF convention with the LAMMPS "cell_origin" extension.
+type AmberWriter struct {
+	*Writer
+	AtomsCount int
+
+	framesCount int
+	properties  []string
+}
+
+/*
+NewAmberWriter defines the trajectory variables and writes the file header.
+
+Params:
+  - writer: the destination
+  - atomsCount: the number of atoms in every frame
+  - withVelocities: whether the frames have velocities
+  - properties: the names of the per-atom custom properties taken from Frame.Properties
+
+Returns:
+  - AmberWriter: the writer of the frames
+  - error: any error occured
+*/
+func NewAmberWriter(writer io.WriterAt, atomsCount int, withVelocities bool, properties []string) (*AmberWriter, error) {
+	file := &File{
+		Version: VERSION_64BIT_OFFSET,
+		Dimensions: []Dimension{
+			{Name: dimensionFrame, Length: 0},
+			{Name: dimensionSpatial, Length: 3},
+			{Name: dimensionAtom, Length: atomsCount},
+			{Name: dimensionCellSpatial, Length: 3},
+			{Name: dimensionCellAngular, Length: 3},
+			{Name: dimensionLabel, Length: 5},
+		},
+		Attributes: []Attribute{
+			textAttribute(attributeConventions, amberConventions),
+			textAttribute("ConventionVersion", amberConventionVersion),
+			textAttribute("program", "lammps-file-parser"),
+			textAttribute("programVersion", "1.0"),
+		},
+	}
+	const (
+		frame = iota
+		spatial
+		atom
+		cellSpatial
+		cellAngular
+		label
+	)
+	file.Variables = []*Variable{
+		{Name: variableSpatial, Dimensions: []int{spatial}, Type: NC_CHAR},
+		{Name: variableCellSpatial, Dimensions: []int{cellSpatial}, Type: NC_CHAR},
+		{Name: variableCellAngular, Dimensions: []int{cellAngular, label}, Type: NC_CHAR},
+		{Name: variableTime, Dimensions: []int{frame}, Type: NC_FLOAT,
+			Attributes: []Attribute{textAttribute("units", "picosecond")}},
+		{Name: variableStep, Dimensions: []int{frame}, Type: NC_INT},
+		{Name: variableCellOrigin, Dimensions: []int{frame, cellSpatial}, Type: NC_DOUBLE,
+			Attributes: []Attribute{textAttribute("units", "angstrom")}},
+		{Name: variableCellLengths, Dimensions: []int{frame, cellSpatial}, Type: NC_DOUBLE,
+			Attributes: []Attribute{textAttribute("units", "angstrom")}},
+		{Name: variableCellAngles, Dimensions: []int{frame, cellAngular}, Type: NC_DOUBLE,
+			Attributes: []Attribute{textAttribute("units", "degree")}},
+		{Name: variableCoordinates, Dimensions: []int{frame, atom, spatial}, Type: NC_FLOAT,
+			Attributes: []Attribute{textAttribute("units", "angstrom")}},
+	}
+	if withVelocities {
+		file.Variables = append(file.Variables, &Variable{
+			Name: variableVelocities, Dimensions: []int{frame, atom, spatial}, Type: NC_FLOAT,
+			Attributes: []Attribute{textAttribute("units", "angstrom/picosecond")},
+		})
+	}
+	for _, property := range properties {
+		if file.Variable(property) != nil {
+			return nil, fmt.Errorf("property %s clashes with a variable of the convention", property)
+		}
+		file.Variables = append(file.Variables, &Variable{
+			Name: property, Dimensions: []int{frame, atom}, Type: NC_DOUBLE,
+		})
+	}
+
+	netcdfWriter, err := Create(writer, file)
+	if err != nil {
+		return nil, err
+	}
+	texts := map[string]string{
+		variableSpatial:     "xyz",
+		variableCellSpatial: "abc",
+		variableCellAngular: "alpha" + "beta " + "gamma",
+	}
+	for name, text := range texts {
+		if err := netcdfWriter.Write(file.Variable(name), 0, text); err != nil {
+			return nil, err
+		}
+	}
+	return &AmberWriter{
+		Writer:     netcdfWriter,
+		AtomsCount: atomsCount,
+		properties: properties,
+	}, nil
+}
+
+func textAttribute(name, value string) Attribute {
+	return Attribute{Name: name, Type: NC_CHAR, Value: value}
+}
+
+func flatten(vectors []structs.AtomCoords) []float64 {
+	values := make([]float64, 0, 3*len(vectors))
+	for _, vector := range vectors {
+		values = append(values, vector.X, vector.Y, vector.Z)
+	}
+	return values
+}
+
+// WriteFrame appends the frame to the file.
+func (amber *AmberWriter) WriteFrame(frame *structs.Frame) error {
+	if len(frame.Coords) != amber.AtomsCount {
+		return fmt.Errorf("the frame has %d atoms, but the file has %d", len(frame.Coords), amber.AtomsCount)
+	}
+	record := amber.framesCount
+	lengths, angles := structs.CellFromBox(frame.SpaceDimention, frame.TiltFactors)
+	origin := []float64{
+		frame.SpaceDimention[structs.DIMENTION_TYPE_X][0],
+		frame.SpaceDimention[structs.DIMENTION_TYPE_Y][0],
+		frame.SpaceDimention[structs.DIMENTION_TYPE_Z][0],
+	}
+	values := map[string][]float64{
+		variableTime:        {frame.Time},
+		variableStep:        {float64(frame.Timestep)},
+		variableCellOrigin:  origin,
+		variableCellLengths: lengths[:],
+		variableCellAngles:  angles[:],
+		variableCoordinates: flatten(frame.Coords),
+	}
+	if velocities := amber.Variable(variableVelocities); velocities != nil {
+		if len(frame.Velocities) != amber.AtomsCount {
+			return fmt.Errorf("the frame has %d velocities, but the file has %d atoms", len(frame.Velocities), amber.AtomsCount)
+		}
+		values[variableVelocities] = flatten(frame.Velocities)
+	}
+	for _, property := range amber.properties {
+		column, ok := frame.Properties[property]
+		if !ok {
+			return fmt.Errorf("the frame has no property %s", property)
+		}
+		values[property] = column
+	}
+	for name, data := range values {
+		if err := amber.Write(amber.Variable(name), record, data); err != nil {
+			return err
+		}
+	}
+	amber.framesCount++
+	return nil
+}
@@ -0,0 +1,8 @@
+/*
+Package netcdf reads and writes the NetCDF classic and 64-bit offset binary formats
+without any dependency on the NetCDF or HDF5 libraries. On top of the generic File it
+implements the AMBER trajectory convention that is also written by the LAMMPS
+"dump netcdf" command: "coordinates", "velocities", "cell_lengths", "cell_angles",
+"cell_origin" and per-atom custom variables are mapped onto structs.Frame.
+*/
+package netcdf
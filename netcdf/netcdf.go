@@ -0,0 +1,217 @@
+package netcdf
+
+import "fmt"
+
+type Type = int32
+
+// The external data types of the classic format.
+const (
+	NC_BYTE Type = iota + 1
+	NC_CHAR
+	NC_SHORT
+	NC_INT
+	NC_FLOAT
+	NC_DOUBLE
+)
+
+// The format versions stored after the "CDF" signature.
+const (
+	VERSION_CLASSIC      = 1
+	VERSION_64BIT_OFFSET = 2
+)
+
+const (
+	tagDimension = 0x0A
+	tagVariable  = 0x0B
+	tagAttribute = 0x0C
+
+	streamingRecords = 0xFFFFFFFF
+)
+
+func typeSize(dataType Type) (int, error) {
+	switch dataType {
+	case NC_BYTE, NC_CHAR:
+		return 1, nil
+	case NC_SHORT:
+		return 2, nil
+	case NC_INT, NC_FLOAT:
+		return 4, nil
+	case NC_DOUBLE:
+		return 8, nil
+	}
+	return 0, fmt.Errorf("unknown NetCDF data type: %d", dataType)
+}
+
+// Dimension is a named dimension. A zero Length marks the unlimited (record) dimension.
+type Dimension struct {
+	Name   string
+	Length int
+}
+
+/*
+Attribute is a named attribute of a file or a variable. Value is a string for NC_CHAR
+and a slice of []int8, []int16, []int32, []float32 or []float64 for the other types.
+*/
+type Attribute struct {
+	Name  string
+	Type  Type
+	Value any
+}
+
+// Variable describes a variable and the location of its data in the file.
+type Variable struct {
+	Name       string
+	Dimensions []int // indices in File.Dimensions
+	Attributes []Attribute
+	Type       Type
+
+	isRecord bool
+	// slabSize is the number of values in the variable (in one record for record variables)
+	slabSize int
+	begin    int64
+}
+
+// IsRecord reports whether the variable depends on the unlimited dimension.
+func (variable *Variable) IsRecord() bool {
+	return variable.isRecord
+}
+
+// Attribute returns the attribute with the given name, or nil.
+func (variable *Variable) Attribute(name string) *Attribute {
+	return findAttribute(variable.Attributes, name)
+}
+
+func findAttribute(attributes []Attribute, name string) *Attribute {
+	for i := range attributes {
+		if attributes[i].Name == name {
+			return &attributes[i]
+		}
+	}
+	return nil
+}
+
+// Float returns the first value of a numeric attribute.
+func (attribute *Attribute) Float() (float64, bool) {
+	switch values := attribute.Value.(type) {
+	case []int8:
+		if len(values) > 0 {
+			return float64(values[0]), true
+		}
+	case []int16:
+		if len(values) > 0 {
+			return float64(values[0]), true
+		}
+	case []int32:
+		if len(values) > 0 {
+			return float64(values[0]), true
+		}
+	case []float32:
+		if len(values) > 0 {
+			return float64(values[0]), true
+		}
+	case []float64:
+		if len(values) > 0 {
+			return values[0], true
+		}
+	}
+	return 0, false
+}
+
+// String returns the value of a text attribute.
+func (attribute *Attribute) String() string {
+	if text, ok := attribute.Value.(string); ok {
+		return text
+	}
+	return fmt.Sprint(attribute.Value)
+}
+
+// File is the header of a NetCDF file: its dimensions, attributes and variables.
+type File struct {
+	Version      int
+	RecordsCount int
+	Dimensions   []Dimension
+	Attributes   []Attribute
+	Variables    []*Variable
+
+	recordSize int64
+}
+
+// Variable returns the variable with the given name, or nil.
+func (file *File) Variable(name string) *Variable {
+	for _, variable := range file.Variables {
+		if variable.Name == name {
+			return variable
+		}
+	}
+	return nil
+}
+
+// Attribute returns the global attribute with the given name, or nil.
+func (file *File) Attribute(name string) *Attribute {
+	return findAttribute(file.Attributes, name)
+}
+
+// Dimension returns the index of the dimension with the given name, or -1.
+func (file *File) Dimension(name string) int {
+	for i, dimension := range file.Dimensions {
+		if dimension.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Shape returns the lengths of the variable dimensions; the record dimension has the records count.
+func (file *File) Shape(variable *Variable) []int {
+	shape := make([]int, len(variable.Dimensions))
+	for i, dimension := range variable.Dimensions {
+		shape[i] = file.Dimensions[dimension].Length
+		if shape[i] == 0 {
+			shape[i] = file.RecordsCount
+		}
+	}
+	return shape
+}
+
+// layout computes the slab sizes of the variables and the size of a record.
+func (file *File) layout() error {
+	recordVariables := 0
+	file.recordSize = 0
+	var lastRecordBytes int64
+	for _, variable := range file.Variables {
+		size, err := typeSize(variable.Type)
+		if err != nil {
+			return err
+		}
+		variable.isRecord = false
+		variable.slabSize = 1
+		for i, dimension := range variable.Dimensions {
+			if dimension < 0 || dimension >= len(file.Dimensions) {
+				return fmt.Errorf("variable %s refers to the unknown dimension %d", variable.Name, dimension)
+			}
+			length := file.Dimensions[dimension].Length
+			if length == 0 {
+				if i != 0 {
+					return fmt.Errorf("variable %s: the unlimited dimension must be the first one", variable.Name)
+				}
+				variable.isRecord = true
+				continue
+			}
+			variable.slabSize *= length
+		}
+		if variable.isRecord {
+			recordVariables++
+			lastRecordBytes = int64(variable.slabSize * size)
+			file.recordSize += padded(lastRecordBytes)
+		}
+	}
+	// a single record variable is not padded
+	if recordVariables == 1 {
+		file.recordSize = lastRecordBytes
+	}
+	return nil
+}
+
+func padded(size int64) int64 {
+	return (size + 3) / 4 * 4
+}
@@ -0,0 +1,336 @@
+package netcdf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// _HeaderReader parses the big-endian header of a NetCDF file.
+type _HeaderReader struct {
+	reader  *bufio.Reader
+	version int
+}
+
+func (header *_HeaderReader) readInt() (int32, error) {
+	var value int32
+	err := binary.Read(header.reader, binary.BigEndian, &value)
+	return value, unexpectedEOF(err)
+}
+
+func (header *_HeaderReader) readCount() (int, error) {
+	value, err := header.readInt()
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("negative count in the NetCDF header: %d", value)
+	}
+	return int(value), nil
+}
+
+func (header *_HeaderReader) readOffset() (int64, error) {
+	if header.version == VERSION_64BIT_OFFSET {
+		var value int64
+		err := binary.Read(header.reader, binary.BigEndian, &value)
+		return value, unexpectedEOF(err)
+	}
+	value, err := header.readInt()
+	return int64(uint32(value)), err
+}
+
+func (header *_HeaderReader) readPadded(size int) ([]byte, error) {
+	data := make([]byte, padded(int64(size)))
+	if _, err := io.ReadFull(header.reader, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return data[:size], nil
+}
+
+func (header *_HeaderReader) readName() (string, error) {
+	size, err := header.readCount()
+	if err != nil {
+		return "", err
+	}
+	name, err := header.readPadded(size)
+	return string(name), err
+}
+
+// readList reads the tag and the number of elements of a list; an absent list has zero elements.
+func (header *_HeaderReader) readList(tag int32) (int, error) {
+	listTag, err := header.readInt()
+	if err != nil {
+		return 0, err
+	}
+	count, err := header.readCount()
+	if err != nil {
+		return 0, err
+	}
+	if listTag == 0 && count == 0 {
+		return 0, nil
+	}
+	if listTag != tag {
+		return 0, fmt.Errorf("unexpected tag in the NetCDF header: %#x instead of %#x", listTag, tag)
+	}
+	return count, nil
+}
+
+func (header *_HeaderReader) readAttributes() ([]Attribute, error) {
+	count, err := header.readList(tagAttribute)
+	if err != nil {
+		return nil, err
+	}
+	attributes := make([]Attribute, count)
+	for i := range attributes {
+		if attributes[i].Name, err = header.readName(); err != nil {
+			return nil, err
+		}
+		if attributes[i].Type, err = header.readInt(); err != nil {
+			return nil, err
+		}
+		valuesCount, err := header.readCount()
+		if err != nil {
+			return nil, err
+		}
+		size, err := typeSize(attributes[i].Type)
+		if err != nil {
+			return nil, err
+		}
+		data, err := header.readPadded(valuesCount * size)
+		if err != nil {
+			return nil, err
+		}
+		if attributes[i].Value, err = decodeValues(attributes[i].Type, data); err != nil {
+			return nil, err
+		}
+	}
+	return attributes, nil
+}
+
+/*
+Open reads the header of a NetCDF classic or 64-bit offset file.
+The returned Reader reads the data from the reader on demand.
+
+Params:
+  - reader: a NetCDF file content
+
+Returns:
+  - Reader: the file header and the access to the data
+  - error: any error occured
+*/
+func Open(reader io.ReaderAt) (*Reader, error) {
+	header := &_HeaderReader{reader: bufio.NewReader(io.NewSectionReader(reader, 0, math.MaxInt64))}
+	magic, err := header.readPadded(4)
+	if err != nil {
+		return nil, err
+	}
+	if string(magic[:3]) != "CDF" {
+		return nil, errors.New("not a NetCDF classic file")
+	}
+	header.version = int(magic[3])
+	if header.version != VERSION_CLASSIC && header.version != VERSION_64BIT_OFFSET {
+		return nil, fmt.Errorf("unsupported NetCDF format version %d (HDF5 based files are not supported)", header.version)
+	}
+	file := &File{Version: header.version}
+
+	recordsCount, err := header.readInt()
+	if err != nil {
+		return nil, err
+	}
+	if uint32(recordsCount) == streamingRecords {
+		return nil, errors.New("NetCDF files in the streaming mode are not supported")
+	}
+	file.RecordsCount = int(recordsCount)
+
+	dimensionsCount, err := header.readList(tagDimension)
+	if err != nil {
+		return nil, err
+	}
+	file.Dimensions = make([]Dimension, dimensionsCount)
+	for i := range file.Dimensions {
+		if file.Dimensions[i].Name, err = header.readName(); err != nil {
+			return nil, err
+		}
+		if file.Dimensions[i].Length, err = header.readCount(); err != nil {
+			return nil, err
+		}
+	}
+
+	if file.Attributes, err = header.readAttributes(); err != nil {
+		return nil, err
+	}
+
+	variablesCount, err := header.readList(tagVariable)
+	if err != nil {
+		return nil, err
+	}
+	file.Variables = make([]*Variable, variablesCount)
+	for i := range file.Variables {
+		variable := &Variable{}
+		if variable.Name, err = header.readName(); err != nil {
+			return nil, err
+		}
+		rank, err := header.readCount()
+		if err != nil {
+			return nil, err
+		}
+		variable.Dimensions = make([]int, rank)
+		for k := range variable.Dimensions {
+			if variable.Dimensions[k], err = header.readCount(); err != nil {
+				return nil, err
+			}
+		}
+		if variable.Attributes, err = header.readAttributes(); err != nil {
+			return nil, err
+		}
+		if variable.Type, err = header.readInt(); err != nil {
+			return nil, err
+		}
+		// the stored size may be clipped for huge variables, it is recomputed by the layout
+		if _, err := header.readInt(); err != nil {
+			return nil, err
+		}
+		if variable.begin, err = header.readOffset(); err != nil {
+			return nil, err
+		}
+		file.Variables[i] = variable
+	}
+	if err := file.layout(); err != nil {
+		return nil, err
+	}
+	return &Reader{File: file, reader: reader}, nil
+}
+
+// Reader gives access to the data of the variables of an opened file.
+type Reader struct {
+	*File
+	reader io.ReaderAt
+}
+
+/*
+Read reads the values of the variable converted to float64. For record variables
+it reads the given record, for the others the record is ignored. The "scale_factor"
+attribute is not applied here.
+*/
+func (reader *Reader) Read(variable *Variable, record int) ([]float64, error) {
+	data, err := reader.ReadRaw(variable, record)
+	if err != nil {
+		return nil, err
+	}
+	return toFloats(variable.Type, data)
+}
+
+// ReadRaw reads the values of the variable without any conversion.
+func (reader *Reader) ReadRaw(variable *Variable, record int) ([]byte, error) {
+	size, err := typeSize(variable.Type)
+	if err != nil {
+		return nil, err
+	}
+	offset := variable.begin
+	if variable.isRecord {
+		if record < 0 || record >= reader.RecordsCount {
+			return nil, fmt.Errorf("record %d is out of bounds (records: %d)", record, reader.RecordsCount)
+		}
+		offset += int64(record) * reader.recordSize
+	}
+	data := make([]byte, variable.slabSize*size)
+	if n, err := reader.reader.ReadAt(data, offset); err != nil && !(err == io.EOF && n == len(data)) {
+		return nil, fmt.Errorf("could not read the variable %s: %w", variable.Name, unexpectedEOF(err))
+	}
+	return data, nil
+}
+
+// ReadText reads a character variable as a string with the trailing zero bytes removed.
+func (reader *Reader) ReadText(variable *Variable, record int) (string, error) {
+	if variable.Type != NC_CHAR {
+		return "", fmt.Errorf("variable %s is not a text", variable.Name)
+	}
+	data, err := reader.ReadRaw(variable, record)
+	if err != nil {
+		return "", err
+	}
+	end := len(data)
+	for end > 0 && data[end-1] == 0 {
+		end--
+	}
+	return string(data[:end]), nil
+}
+
+func decodeValues(dataType Type, data []byte) (any, error) {
+	switch dataType {
+	case NC_CHAR:
+		end := len(data)
+		for end > 0 && data[end-1] == 0 {
+			end--
+		}
+		return string(data[:end]), nil
+	case NC_BYTE:
+		values := make([]int8, len(data))
+		for i := range data {
+			values[i] = int8(data[i])
+		}
+		return values, nil
+	case NC_SHORT:
+		values := make([]int16, len(data)/2)
+		for i := range values {
+			values[i] = int16(binary.BigEndian.Uint16(data[2*i:]))
+		}
+		return values, nil
+	case NC_INT:
+		values := make([]int32, len(data)/4)
+		for i := range values {
+			values[i] = int32(binary.BigEndian.Uint32(data[4*i:]))
+		}
+		return values, nil
+	case NC_FLOAT:
+		values := make([]float32, len(data)/4)
+		for i := range values {
+			values[i] = math.Float32frombits(binary.BigEndian.Uint32(data[4*i:]))
+		}
+		return values, nil
+	case NC_DOUBLE:
+		values := make([]float64, len(data)/8)
+		for i := range values {
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(data[8*i:]))
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unknown NetCDF data type: %d", dataType)
+}
+
+func toFloats(dataType Type, data []byte) ([]float64, error) {
+	if dataType == NC_CHAR {
+		return nil, errors.New("text variables can not be read as numbers")
+	}
+	size, err := typeSize(dataType)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(data)/size)
+	for i := range values {
+		chunk := data[i*size:]
+		switch dataType {
+		case NC_BYTE:
+			values[i] = float64(int8(chunk[0]))
+		case NC_SHORT:
+			values[i] = float64(int16(binary.BigEndian.Uint16(chunk)))
+		case NC_INT:
+			values[i] = float64(int32(binary.BigEndian.Uint32(chunk)))
+		case NC_FLOAT:
+			values[i] = float64(math.Float32frombits(binary.BigEndian.Uint32(chunk)))
+		case NC_DOUBLE:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(chunk))
+		}
+	}
+	return values, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
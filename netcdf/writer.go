@@ -0,0 +1,244 @@
+package netcdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Writer writes the data of the variables of a file defined in advance.
+type Writer struct {
+	*File
+	writer io.WriterAt
+}
+
+/*
+Create writes the header of the file and prepares the writing of its data.
+The layout (the offsets of the variables) is computed from the definition,
+so dimensions, attributes and variables may not be changed afterwards.
+
+Params:
+  - writer: the destination
+  - file: the definition of the dimensions, attributes and variables
+
+Returns:
+  - Writer: the writer of the data
+  - error: any error occured
+*/
+func Create(writer io.WriterAt, file *File) (*Writer, error) {
+	if file.Version == 0 {
+		file.Version = VERSION_64BIT_OFFSET
+	}
+	if file.Version != VERSION_CLASSIC && file.Version != VERSION_64BIT_OFFSET {
+		return nil, fmt.Errorf("unsupported NetCDF format version %d", file.Version)
+	}
+	file.RecordsCount = 0
+	if err := file.layout(); err != nil {
+		return nil, err
+	}
+
+	// the first pass only measures the header
+	header, err := file.encodeHeader()
+	if err != nil {
+		return nil, err
+	}
+	offset := int64(len(header))
+	for _, variable := range file.Variables {
+		if !variable.isRecord {
+			variable.begin = offset
+			size, _ := typeSize(variable.Type)
+			offset += padded(int64(variable.slabSize * size))
+		}
+	}
+	for _, variable := range file.Variables {
+		if variable.isRecord {
+			variable.begin = offset
+			size, _ := typeSize(variable.Type)
+			offset += padded(int64(variable.slabSize * size))
+		}
+	}
+	if file.Version == VERSION_CLASSIC && offset > math.MaxInt32 {
+		return nil, fmt.Errorf("the variables do not fit the classic format, use the 64-bit offset one")
+	}
+
+	if header, err = file.encodeHeader(); err != nil {
+		return nil, err
+	}
+	if _, err := writer.WriteAt(header, 0); err != nil {
+		return nil, err
+	}
+	return &Writer{File: file, writer: writer}, nil
+}
+
+/*
+Write writes the values of the variable. For record variables it writes the given record
+and extends the records count if needed, for the others the record is ignored.
+Values are a string for NC_CHAR variables and a []float64 for the numeric ones.
+*/
+func (writer *Writer) Write(variable *Variable, record int, values any) error {
+	data, err := encodeVariable(variable, values)
+	if err != nil {
+		return err
+	}
+	offset := variable.begin
+	if variable.isRecord {
+		if record < 0 {
+			return fmt.Errorf("negative record: %d", record)
+		}
+		offset += int64(record) * writer.recordSize
+		if record >= writer.RecordsCount {
+			writer.RecordsCount = record + 1
+		}
+	}
+	_, err = writer.writer.WriteAt(data, offset)
+	return err
+}
+
+// Close writes the final records count into the header. It does not close the destination itself.
+func (writer *Writer) Close() error {
+	var data [4]byte
+	binary.BigEndian.PutUint32(data[:], uint32(writer.RecordsCount))
+	_, err := writer.writer.WriteAt(data[:], 4)
+	return err
+}
+
+func encodeVariable(variable *Variable, values any) ([]byte, error) {
+	size, err := typeSize(variable.Type)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, variable.slabSize*size)
+	if variable.Type == NC_CHAR {
+		text, ok := values.(string)
+		if !ok {
+			return nil, fmt.Errorf("variable %s expects a string", variable.Name)
+		}
+		if len(text) > len(data) {
+			return nil, fmt.Errorf("the text is too long for the variable %s", variable.Name)
+		}
+		copy(data, text)
+		return data, nil
+	}
+	numbers, ok := values.([]float64)
+	if !ok {
+		return nil, fmt.Errorf("variable %s expects a []float64", variable.Name)
+	}
+	if len(numbers) != variable.slabSize {
+		return nil, fmt.Errorf("variable %s expects %d values, got %d", variable.Name, variable.slabSize, len(numbers))
+	}
+	for i, number := range numbers {
+		chunk := data[i*size:]
+		switch variable.Type {
+		case NC_BYTE:
+			chunk[0] = byte(int8(math.Round(number)))
+		case NC_SHORT:
+			binary.BigEndian.PutUint16(chunk, uint16(int16(math.Round(number))))
+		case NC_INT:
+			binary.BigEndian.PutUint32(chunk, uint32(int32(math.Round(number))))
+		case NC_FLOAT:
+			binary.BigEndian.PutUint32(chunk, math.Float32bits(float32(number)))
+		case NC_DOUBLE:
+			binary.BigEndian.PutUint64(chunk, math.Float64bits(number))
+		}
+	}
+	return data, nil
+}
+
+// _HeaderWriter encodes the big-endian header of a NetCDF file.
+type _HeaderWriter struct {
+	buffer  bytes.Buffer
+	version int
+}
+
+func (header *_HeaderWriter) writeInt(value int) {
+	binary.Write(&header.buffer, binary.BigEndian, int32(value))
+}
+
+func (header *_HeaderWriter) writePadded(data []byte) {
+	header.buffer.Write(data)
+	header.buffer.Write(make([]byte, padded(int64(len(data)))-int64(len(data))))
+}
+
+func (header *_HeaderWriter) writeName(name string) {
+	header.writeInt(len(name))
+	header.writePadded([]byte(name))
+}
+
+func (header *_HeaderWriter) writeList(tag, count int) {
+	if count == 0 {
+		tag = 0
+	}
+	header.writeInt(tag)
+	header.writeInt(count)
+}
+
+func (header *_HeaderWriter) writeAttributes(attributes []Attribute) error {
+	header.writeList(tagAttribute, len(attributes))
+	for _, attribute := range attributes {
+		header.writeName(attribute.Name)
+		header.writeInt(int(attribute.Type))
+		var data bytes.Buffer
+		count := 0
+		switch values := attribute.Value.(type) {
+		case string:
+			if attribute.Type != NC_CHAR {
+				return fmt.Errorf("attribute %s: a string needs the NC_CHAR type", attribute.Name)
+			}
+			data.WriteString(values)
+			count = len(values)
+		case []int8, []int16, []int32, []float32, []float64:
+			if err := binary.Write(&data, binary.BigEndian, values); err != nil {
+				return err
+			}
+			size, err := typeSize(attribute.Type)
+			if err != nil {
+				return err
+			}
+			count = data.Len() / size
+		default:
+			return fmt.Errorf("attribute %s has an unsupported value %T", attribute.Name, attribute.Value)
+		}
+		header.writeInt(count)
+		header.writePadded(data.Bytes())
+	}
+	return nil
+}
+
+func (file *File) encodeHeader() ([]byte, error) {
+	header := &_HeaderWriter{version: file.Version}
+	header.buffer.WriteString("CDF")
+	header.buffer.WriteByte(byte(file.Version))
+	header.writeInt(file.RecordsCount)
+
+	header.writeList(tagDimension, len(file.Dimensions))
+	for _, dimension := range file.Dimensions {
+		header.writeName(dimension.Name)
+		header.writeInt(dimension.Length)
+	}
+	if err := header.writeAttributes(file.Attributes); err != nil {
+		return nil, err
+	}
+
+	header.writeList(tagVariable, len(file.Variables))
+	for _, variable := range file.Variables {
+		header.writeName(variable.Name)
+		header.writeInt(len(variable.Dimensions))
+		for _, dimension := range variable.Dimensions {
+			header.writeInt(dimension)
+		}
+		if err := header.writeAttributes(variable.Attributes); err != nil {
+			return nil, err
+		}
+		header.writeInt(int(variable.Type))
+		size, _ := typeSize(variable.Type)
+		header.writeInt(int(min(padded(int64(variable.slabSize*size)), math.MaxUint32)))
+		if file.Version == VERSION_64BIT_OFFSET {
+			binary.Write(&header.buffer, binary.BigEndian, variable.begin)
+		} else {
+			header.writeInt(int(variable.begin))
+		}
+	}
+	return header.buffer.Bytes(), nil
+}
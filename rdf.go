@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Ivanestver/lammps-file-parser/rdf"
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+var rdfCommand = _Command{
+	name: "rdf",
+	usage: "-cutoff R [-bins N] [-types \"1 1 1 2\"] [-molecules all|intra|inter] [-topology data] [-format trajectory] " +
+		"[-json] [-o output] input...",
+	description: "Compute the radial distribution functions and the coordination numbers from data files or trajectories.",
+	run:         runRDF,
+}
+
+var moleculeFilters = map[string]rdf.MoleculeFilter{
+	"all":   rdf.ALL_PAIRS,
+	"intra": rdf.INTRA_MOLECULAR,
+	"inter": rdf.INTER_MOLECULAR,
+}
+
+func runRDF(command *_Command, args []string) error {
+	flags := newFlagSet(command)
+	cutoff := flags.Float64("cutoff", 0, "the largest distance, not more than half the box width")
+	bins := flags.Int("bins", 100, "number of the bins")
+	types := flags.String("types", "", "pairs of the types of the central atoms and of their neighbours like in compute rdf, "+
+		"e.g. \"1 1 1 2*3\" (all the types with all the types by default)")
+	molecules := flags.String("molecules", "all", "the pairs to count: all, intra (within a molecule) or inter (between molecules)")
+	topologyFile := flags.String("topology", "", "data file with the types and the molecules of the atoms of the trajectories")
+	format := flags.String("format", "", "trajectory format: dump, dump-binary, dcd, xtc, trr or netcdf "+
+		"(detected by the extension by default; the other files are data files)")
+	asJSON := flags.Bool("json", false, "print JSON instead of CSV")
+	output := flags.String("o", stdio, "output file")
+	from := flags.String("from", "", "format of the data files: lammps or json (detected by the extension by default)")
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return usageErrorf("expected at least one input file")
+	}
+	if *cutoff <= 0 {
+		return usageErrorf("expected a positive cutoff")
+	}
+	if *bins <= 0 {
+		return usageErrorf("expected a positive number of the bins")
+	}
+	pairs, err := rdf.ParseTypePairs(*types)
+	if err != nil {
+		return usageErrorf("wrong types: %s", err.Error())
+	}
+	moleculeFilter, ok := moleculeFilters[*molecules]
+	if !ok {
+		return usageErrorf("unknown molecules filter %q", *molecules)
+	}
+	calculator, err := rdf.NewCalculator(rdf.Options{Bins: *bins, Cutoff: *cutoff, Pairs: pairs, Molecules: moleculeFilter})
+	if err != nil {
+		return err
+	}
+	var topology *structs.LammpsStruct
+	if len(*topologyFile) != 0 {
+		if topology, err = readStruct(*topologyFile, *from); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		if err := addFrames(calculator, file, *format, *from, topology); err != nil {
+			return err
+		}
+	}
+	result := calculator.Result()
+	return writeReport(*output, *asJSON, result, result.WriteCSV)
+}
+
+// addFrames adds a data file or all the frames of a trajectory to the calculator.
+func addFrames(calculator *rdf.Calculator, filename, format, from string, topology *structs.LammpsStruct) error {
+	format, err := trajectoryFormat(format, filename)
+	if err != nil {
+		return err
+	}
+	if len(format) == 0 {
+		lammpsStruct, err := readStruct(filename, from)
+		if err != nil {
+			return err
+		}
+		if err := calculator.AddStruct(lammpsStruct); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		return nil
+	}
+
+	next, closeFile, err := openFrames(filename, format)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+	for {
+		frame, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		if err := calculator.AddFrame(frame, topology); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+}
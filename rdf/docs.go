@@ -0,0 +1,21 @@
+/*
+Package rdf computes the radial distribution functions g(r) and the coordination numbers of the
+atoms of a data file or of the frames of a trajectory, like the LAMMPS "compute rdf" command
+does during a run.
+
+The pairs of atoms are chosen by the types of the central atoms and of their neighbours in the
+LAMMPS notation: a type "2", a range "1*3", the open ranges "*2" and "2*" or all the types "*".
+The distances follow the minimum image convention in orthogonal and triclinic periodic boxes,
+so the cutoff may not exceed half the width of the box. The pairs may be restricted to the atoms
+of the same molecule or of different molecules.
+
+For every bin the function is normalised like in LAMMPS by the number of the pairs of the types
+and the volume of the spherical shell relative to the box, and the coordination number is the
+average number of the neighbours of a central atom closer than the upper edge of the bin.
+The frames are averaged, so the boxes of the frames may differ:
+
+	calculator, err := rdf.NewCalculator(rdf.Options{Bins: 100, Cutoff: 10})
+	calculator.AddStruct(lammpsStruct)
+	result := calculator.Result()
+*/
+package rdf
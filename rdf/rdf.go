@@ -0,0 +1,221 @@
+package rdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+type MoleculeFilter = int
+
+const (
+	// ALL_PAIRS counts every pair of atoms.
+	ALL_PAIRS MoleculeFilter = iota
+	// INTRA_MOLECULAR counts the pairs of atoms of the same molecule only.
+	INTRA_MOLECULAR
+	// INTER_MOLECULAR counts the pairs of atoms of different molecules only; the atoms with the
+	// molecule ID 0 belong to no molecule, so they are in a different molecule from any atom.
+	INTER_MOLECULAR
+)
+
+// Options set the bins, the cutoff and the pairs of a Calculator.
+type Options struct {
+	Bins   int
+	Cutoff float64
+	// Pairs are the pairs of the atom types; no pairs mean one pair of all the types with all the types
+	Pairs     []TypePair
+	Molecules MoleculeFilter
+}
+
+// Calculator sums the radial distribution functions over the structures and frames added to it.
+type Calculator struct {
+	options Options
+	pairs   []TypePair
+	// the sums of the functions and of the coordination numbers over the frames by the pairs and the bins
+	functions     [][]float64
+	coordinations [][]float64
+	framesCount   int
+}
+
+/*
+NewCalculator checks the options and returns an empty Calculator.
+
+Params:
+  - options: the bins, the cutoff, the pairs of the types and the molecules filter
+
+Returns:
+  - Calculator: the calculator with no frames
+  - error: a non-positive number of the bins or cutoff or an unknown molecules filter
+*/
+func NewCalculator(options Options) (*Calculator, error) {
+	if options.Bins <= 0 {
+		return nil, errors.New("the number of the bins must be positive")
+	}
+	if options.Cutoff <= 0 {
+		return nil, errors.New("the cutoff must be positive")
+	}
+	if options.Molecules != ALL_PAIRS && options.Molecules != INTRA_MOLECULAR && options.Molecules != INTER_MOLECULAR {
+		return nil, fmt.Errorf("unknown molecules filter %d", options.Molecules)
+	}
+	pairs := options.Pairs
+	if len(pairs) == 0 {
+		pairs = []TypePair{{}}
+	}
+	calculator := &Calculator{
+		options:       options,
+		pairs:         pairs,
+		functions:     make([][]float64, len(pairs)),
+		coordinations: make([][]float64, len(pairs)),
+	}
+	for i := range pairs {
+		calculator.functions[i] = make([]float64, options.Bins)
+		calculator.coordinations[i] = make([]float64, options.Bins)
+	}
+	return calculator, nil
+}
+
+/*
+AddStruct adds the atoms of a structure as a frame.
+
+Returns:
+  - error: the box is empty or thinner than twice the cutoff; the frame is not added then
+*/
+func (calculator *Calculator) AddStruct(lammpsStruct *structs.LammpsStruct) error {
+	volume := 1.0
+	for _, bounds := range lammpsStruct.SpaceDimention {
+		volume *= bounds[1] - bounds[0]
+	}
+	if volume <= 0 {
+		return errors.New("the box is empty")
+	}
+	widths := lammpsStruct.BoxWidths()
+	if width := min(widths[0], widths[1], widths[2]); 2*calculator.options.Cutoff > width {
+		return fmt.Errorf("the cutoff %g is more than half the box width %g, so the farther distances cannot be counted by the minimum image", calculator.options.Cutoff, width)
+	}
+
+	atoms := lammpsStruct.Atoms
+	histograms := make([][]float64, len(calculator.pairs))
+	firstCounts, normalizations := make([]float64, len(calculator.pairs)), make([]float64, len(calculator.pairs))
+	inFirst, inSecond := make([][]bool, len(calculator.pairs)), make([][]bool, len(calculator.pairs))
+	for p, pair := range calculator.pairs {
+		histograms[p] = make([]float64, calculator.options.Bins)
+		inFirst[p], inSecond[p] = make([]bool, len(atoms)), make([]bool, len(atoms))
+		secondCount, duplicates := 0.0, 0.0
+		for i, atom := range atoms {
+			inFirst[p][i], inSecond[p][i] = pair.First.Contains(atom.AtomType), pair.Second.Contains(atom.AtomType)
+			if inFirst[p][i] {
+				firstCounts[p]++
+			}
+			if inSecond[p][i] {
+				secondCount++
+			}
+			if inFirst[p][i] && inSecond[p][i] {
+				duplicates++
+			}
+		}
+		// an atom is not its own neighbour, like in LAMMPS
+		normalizations[p] = firstCounts[p]*secondCount - duplicates
+	}
+
+	binWidth := calculator.options.Cutoff / float64(calculator.options.Bins)
+	lammpsStruct.Pairs(calculator.options.Cutoff, func(first, second int, distance float64) {
+		bin := int(distance / binWidth)
+		if bin >= calculator.options.Bins || !calculator.counts(&atoms[first], &atoms[second]) {
+			return
+		}
+		for p := range calculator.pairs {
+			if inFirst[p][first] && inSecond[p][second] {
+				histograms[p][bin]++
+			}
+			if inFirst[p][second] && inSecond[p][first] {
+				histograms[p][bin]++
+			}
+		}
+	})
+
+	for p := range calculator.pairs {
+		coordination := 0.0
+		for bin, count := range histograms[p] {
+			lower, upper := float64(bin)*binWidth, float64(bin+1)*binWidth
+			shareOfVolume := 4 * math.Pi / 3 * (upper*upper*upper - lower*lower*lower) / volume
+			if normalizations[p] > 0 {
+				calculator.functions[p][bin] += count / (shareOfVolume * normalizations[p])
+			}
+			if firstCounts[p] > 0 {
+				coordination += count / firstCounts[p]
+			}
+			calculator.coordinations[p][bin] += coordination
+		}
+	}
+	calculator.framesCount++
+	return nil
+}
+
+// counts checks the molecules filter for a pair of atoms.
+func (calculator *Calculator) counts(first, second *structs.Atom) bool {
+	sameMolecule := first.MoleculeID != 0 && first.MoleculeID == second.MoleculeID
+	switch calculator.options.Molecules {
+	case INTRA_MOLECULAR:
+		return sameMolecule
+	case INTER_MOLECULAR:
+		return !sameMolecule
+	}
+	return true
+}
+
+/*
+AddFrame adds a frame of a trajectory. The atom types are taken from the frame if it has them,
+otherwise from the topology; the molecule IDs are taken from the "mol" property of the frame if
+it has one, otherwise from the topology.
+
+Params:
+  - frame: the coordinates and the box
+  - topology: the structure with the atoms of the frame in the same order, or nil
+
+Returns:
+  - error: the types or the molecules are unknown, the numbers of the atoms differ, or the box
+    does not fit the cutoff; the frame is not added then
+*/
+func (calculator *Calculator) AddFrame(frame *structs.Frame, topology *structs.LammpsStruct) error {
+	if topology != nil && len(topology.Atoms) != len(frame.Coords) {
+		return fmt.Errorf("the frame has %d atoms, but the topology has %d", len(frame.Coords), len(topology.Atoms))
+	}
+	if len(frame.AtomTypes) == 0 && topology == nil {
+		return errors.New("the frame has no atom types; give a topology with the atoms")
+	}
+	molecules, hasMolecules := frame.Properties["mol"]
+	if calculator.options.Molecules != ALL_PAIRS && !hasMolecules && topology == nil {
+		return errors.New("the frame has no molecule IDs; give a topology with the atoms")
+	}
+
+	lammpsStruct := &structs.LammpsStruct{
+		Atoms:          make([]structs.Atom, len(frame.Coords)),
+		SpaceDimention: frame.SpaceDimention,
+		Triclinic:      frame.Triclinic,
+		TiltFactors:    frame.TiltFactors,
+	}
+	for i := range lammpsStruct.Atoms {
+		atom := &lammpsStruct.Atoms[i]
+		if topology != nil {
+			atom.AtomID, atom.AtomType, atom.MoleculeID = topology.Atoms[i].AtomID, topology.Atoms[i].AtomType, topology.Atoms[i].MoleculeID
+		}
+		if i < len(frame.AtomTypes) {
+			atom.AtomType = frame.AtomTypes[i]
+		}
+		if hasMolecules && i < len(molecules) {
+			atom.MoleculeID = int(math.Round(molecules[i]))
+		}
+		atom.AtomCoords = frame.Coords[i]
+	}
+	if err := calculator.AddStruct(lammpsStruct); err != nil {
+		return fmt.Errorf("the frame at the timestep %d: %w", frame.Timestep, err)
+	}
+	return nil
+}
+
+// FramesCount returns the number of the frames added.
+func (calculator *Calculator) FramesCount() int {
+	return calculator.framesCount
+}
@@ -0,0 +1,84 @@
+package rdf
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// Result holds the functions averaged over the frames.
+type Result struct {
+	Bins        int
+	Cutoff      float64
+	FramesCount int
+	// Distances are the centers of the bins
+	Distances []float64
+	Pairs     []PairResult
+}
+
+// PairResult holds the function and the coordination numbers of a pair of the types by the bins.
+type PairResult struct {
+	Types        TypePair
+	Function     []float64
+	Coordination []float64
+}
+
+// Result averages the functions over the frames added so far; with no frames they are zero.
+func (calculator *Calculator) Result() *Result {
+	binWidth := calculator.options.Cutoff / float64(calculator.options.Bins)
+	result := &Result{
+		Bins:        calculator.options.Bins,
+		Cutoff:      calculator.options.Cutoff,
+		FramesCount: calculator.framesCount,
+		Distances:   make([]float64, calculator.options.Bins),
+		Pairs:       make([]PairResult, len(calculator.pairs)),
+	}
+	for bin := range result.Distances {
+		result.Distances[bin] = (float64(bin) + 0.5) * binWidth
+	}
+	for p, pair := range calculator.pairs {
+		result.Pairs[p] = PairResult{
+			Types:        pair,
+			Function:     make([]float64, calculator.options.Bins),
+			Coordination: make([]float64, calculator.options.Bins),
+		}
+		if calculator.framesCount == 0 {
+			continue
+		}
+		for bin := range result.Distances {
+			result.Pairs[p].Function[bin] = calculator.functions[p][bin] / float64(calculator.framesCount)
+			result.Pairs[p].Coordination[bin] = calculator.coordinations[p][bin] / float64(calculator.framesCount)
+		}
+	}
+	return result
+}
+
+/*
+WriteCSV writes the result as a table with a row per bin: the distance, then the function and
+the coordination number of every pair, with the columns named like "g(1-2)" and "coord(1-2)".
+*/
+func (result *Result) WriteCSV(writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+	header := []string{"r"}
+	for _, pair := range result.Pairs {
+		header = append(header, "g("+pair.Types.String()+")", "coord("+pair.Types.String()+")")
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	for bin, distance := range result.Distances {
+		row := []string{formatFloat(distance)}
+		for _, pair := range result.Pairs {
+			row = append(row, formatFloat(pair.Function[bin]), formatFloat(pair.Coordination[bin]))
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', 8, 64)
+}
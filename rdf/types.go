@@ -0,0 +1,123 @@
+package rdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TypeRange is a range of the atom types from Low to High inclusive; a zero bound is open.
+type TypeRange struct {
+	Low  int
+	High int
+}
+
+// TypePair selects the central atoms by the First types and their neighbours by the Second ones.
+type TypePair struct {
+	First  TypeRange
+	Second TypeRange
+}
+
+/*
+ParseTypeRange parses a range of the atom types in the LAMMPS notation.
+
+Params:
+  - text: a type "2", a range "1*3", the open ranges "*2" and "2*" or all the types "*"
+
+Returns:
+  - TypeRange: the range
+  - error: a wrong number or a range with the lower bound greater than the upper one
+*/
+func ParseTypeRange(text string) (TypeRange, error) {
+	text = strings.TrimSpace(text)
+	low, high, isRange := strings.Cut(text, "*")
+	if !isRange {
+		high = low
+	}
+	typeRange := TypeRange{}
+	for _, bound := range []struct {
+		text  string
+		value *int
+	}{{low, &typeRange.Low}, {high, &typeRange.High}} {
+		if len(bound.text) == 0 && isRange {
+			continue
+		}
+		value, err := strconv.Atoi(bound.text)
+		if err != nil || value <= 0 {
+			return TypeRange{}, fmt.Errorf("wrong atom type %q in %q", bound.text, text)
+		}
+		*bound.value = value
+	}
+	if typeRange.Low != 0 && typeRange.High != 0 && typeRange.Low > typeRange.High {
+		return TypeRange{}, fmt.Errorf("the atom types %q are in the descending order", text)
+	}
+	return typeRange, nil
+}
+
+/*
+ParseTypePairs parses the pairs of the type ranges given one after another like in
+"compute rdf", e.g. "1 1 1 2*3" for the pairs 1-1 and 1-2*3.
+
+Returns:
+  - []TypePair: the pairs
+  - error: an odd number of the ranges or a wrong range
+*/
+func ParseTypePairs(text string) ([]TypePair, error) {
+	fields := strings.Fields(text)
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("the atom types must go in pairs, got %d of them", len(fields))
+	}
+	pairs := make([]TypePair, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		first, err := ParseTypeRange(fields[i])
+		if err != nil {
+			return nil, err
+		}
+		second, err := ParseTypeRange(fields[i+1])
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, TypePair{First: first, Second: second})
+	}
+	return pairs, nil
+}
+
+// Contains checks if the atom type is in the range.
+func (typeRange TypeRange) Contains(atomType int) bool {
+	return (typeRange.Low == 0 || atomType >= typeRange.Low) && (typeRange.High == 0 || atomType <= typeRange.High)
+}
+
+// String returns the range in the LAMMPS notation.
+func (typeRange TypeRange) String() string {
+	switch {
+	case typeRange.Low == typeRange.High && typeRange.Low != 0:
+		return strconv.Itoa(typeRange.Low)
+	case typeRange.Low == 0 && typeRange.High == 0:
+		return "*"
+	case typeRange.Low == 0:
+		return "*" + strconv.Itoa(typeRange.High)
+	case typeRange.High == 0:
+		return strconv.Itoa(typeRange.Low) + "*"
+	}
+	return strconv.Itoa(typeRange.Low) + "*" + strconv.Itoa(typeRange.High)
+}
+
+// MarshalText writes the range in the LAMMPS notation, so it is a plain string in JSON.
+func (typeRange TypeRange) MarshalText() ([]byte, error) {
+	return []byte(typeRange.String()), nil
+}
+
+// UnmarshalText reads the range in the LAMMPS notation.
+func (typeRange *TypeRange) UnmarshalText(text []byte) error {
+	parsed, err := ParseTypeRange(string(text))
+	if err != nil {
+		return err
+	}
+	*typeRange = parsed
+	return nil
+}
+
+// String returns the pair like "1-2*3".
+func (pair TypePair) String() string {
+	return pair.First.String() + "-" + pair.Second.String()
+}
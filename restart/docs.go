@@ -0,0 +1,12 @@
+/*
+Package restart reads the binary restart files written by the LAMMPS "write_restart"
+command and converts them into a structs.LammpsStruct, so a data file does not have to be
+produced by LAMMPS first.
+
+The reader understands single-file restarts in the per-atom layout used by LAMMPS since
+2020, the atomic, charge, bond, angle, molecular and full atom styles, and the
+coefficients of a few common force field styles. Anything else stops the reading with
+an error rather than being misread, since the sections of the restart file do not store
+their own sizes and can not be skipped.
+*/
+package restart
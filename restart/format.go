@@ -0,0 +1,168 @@
+package restart
+
+// The flags of the restart sections as enumerated in LAMMPS (src/lmprestart.h).
+const (
+	VERSION = iota
+	SMALLINT
+	TAGINT
+	BIGINT
+	UNITS
+	NTIMESTEP
+	DIMENSION
+	NPROCS
+	PROCGRID
+	NEWTON_PAIR
+	NEWTON_BOND
+	XPERIODIC
+	YPERIODIC
+	ZPERIODIC
+	BOUNDARY
+	ATOM_STYLE
+	NATOMS
+	NTYPES
+	NBONDS
+	NBONDTYPES
+	BOND_PER_ATOM
+	NANGLES
+	NANGLETYPES
+	ANGLE_PER_ATOM
+	NDIHEDRALS
+	NDIHEDRALTYPES
+	DIHEDRAL_PER_ATOM
+	NIMPROPERS
+	NIMPROPERTYPES
+	IMPROPER_PER_ATOM
+	TRICLINIC
+	BOXLO
+	BOXHI
+	XY
+	XZ
+	YZ
+	SPECIAL_LJ
+	SPECIAL_COUL
+	MASS
+	PAIR
+	BOND
+	ANGLE
+	DIHEDRAL
+	IMPROPER
+	MULTIPROC
+	MPIIO
+	PROCSPERFILE
+	PERPROC
+	IMAGEINT
+	BOUNDMIN
+	TIMESTEP
+	ATOM_ID
+	ATOM_MAP_STYLE
+	ATOM_MAP_USER
+	ATOM_SORTFREQ
+	ATOM_SORTBIN
+	COMM_MODE
+	COMM_CUTOFF
+	COMM_VEL
+	EXTRA_BOND_PER_ATOM
+	EXTRA_ANGLE_PER_ATOM
+	EXTRA_DIHEDRAL_PER_ATOM
+	EXTRA_IMPROPER_PER_ATOM
+	EXTRA_SPECIAL_PER_ATOM
+	ATOM_MAXSPECIAL
+	NELLIPSOIDS
+	NLINES
+	NTRIS
+	NBODIES
+	ATIME
+	ATIMESTEP
+	LABELMAP
+)
+
+const (
+	magicString = "LammpS RestartT"
+	endian      = 0x0001
+	// formatRevision is the latest revision of the restart format the reader knows
+	formatRevision = 3
+)
+
+// The kinds of the header values; they define how a value is stored.
+const (
+	kindInt = iota
+	kindBigint
+	kindDouble
+	kindString
+	kindIntVector
+	kindDoubleVector
+)
+
+var headerKinds = map[int32]int{
+	VERSION:                 kindString,
+	SMALLINT:                kindInt,
+	TAGINT:                  kindInt,
+	BIGINT:                  kindInt,
+	IMAGEINT:                kindInt,
+	UNITS:                   kindString,
+	NTIMESTEP:               kindBigint,
+	DIMENSION:               kindInt,
+	NPROCS:                  kindInt,
+	PROCGRID:                kindIntVector,
+	NEWTON_PAIR:             kindInt,
+	NEWTON_BOND:             kindInt,
+	XPERIODIC:               kindInt,
+	YPERIODIC:               kindInt,
+	ZPERIODIC:               kindInt,
+	BOUNDARY:                kindIntVector,
+	ATOM_STYLE:              kindString,
+	NATOMS:                  kindBigint,
+	NTYPES:                  kindInt,
+	NBONDS:                  kindBigint,
+	NBONDTYPES:              kindInt,
+	BOND_PER_ATOM:           kindInt,
+	NANGLES:                 kindBigint,
+	NANGLETYPES:             kindInt,
+	ANGLE_PER_ATOM:          kindInt,
+	NDIHEDRALS:              kindBigint,
+	NDIHEDRALTYPES:          kindInt,
+	DIHEDRAL_PER_ATOM:       kindInt,
+	NIMPROPERS:              kindBigint,
+	NIMPROPERTYPES:          kindInt,
+	IMPROPER_PER_ATOM:       kindInt,
+	TRICLINIC:               kindInt,
+	BOXLO:                   kindDoubleVector,
+	BOXHI:                   kindDoubleVector,
+	XY:                      kindDouble,
+	XZ:                      kindDouble,
+	YZ:                      kindDouble,
+	SPECIAL_LJ:              kindDoubleVector,
+	SPECIAL_COUL:            kindDoubleVector,
+	BOUNDMIN:                kindDoubleVector,
+	TIMESTEP:                kindDouble,
+	ATOM_ID:                 kindInt,
+	ATOM_MAP_STYLE:          kindInt,
+	ATOM_MAP_USER:           kindInt,
+	ATOM_SORTFREQ:           kindInt,
+	ATOM_SORTBIN:            kindDouble,
+	COMM_MODE:               kindInt,
+	COMM_CUTOFF:             kindDouble,
+	COMM_VEL:                kindInt,
+	EXTRA_BOND_PER_ATOM:     kindInt,
+	EXTRA_ANGLE_PER_ATOM:    kindInt,
+	EXTRA_DIHEDRAL_PER_ATOM: kindInt,
+	EXTRA_IMPROPER_PER_ATOM: kindInt,
+	EXTRA_SPECIAL_PER_ATOM:  kindInt,
+	ATOM_MAXSPECIAL:         kindInt,
+	NELLIPSOIDS:             kindBigint,
+	NLINES:                  kindBigint,
+	NTRIS:                   kindBigint,
+	NBODIES:                 kindBigint,
+	ATIME:                   kindDouble,
+	ATIMESTEP:               kindBigint,
+}
+
+// The per-atom fields stored after the common ones (x, tag, type, mask, image, v) for every atom style.
+var atomStyleFields = map[string][]string{
+	"atomic":    {},
+	"charge":    {"q"},
+	"bond":      {"molecule", "bonds"},
+	"angle":     {"molecule", "bonds", "angles"},
+	"molecular": {"molecule", "bonds", "angles", "dihedrals", "impropers"},
+	"full":      {"q", "molecule", "bonds", "angles", "dihedrals", "impropers"},
+}
@@ -0,0 +1,673 @@
+package restart
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+// The kinds of the topology; they index the per-type data of the reader.
+const (
+	typeAtom = iota
+	typeBond
+	typeAngle
+	typeDihedral
+	typeImproper
+	typesKindsCount
+)
+
+// perAtomLayoutDate is the LAMMPS version that introduced the per-atom layout the reader expects.
+var perAtomLayoutDate = time.Date(2020, time.March, 3, 0, 0, 0, 0, time.UTC)
+
+type _Header struct {
+	version      string
+	units        string
+	atomStyle    string
+	imageIntSize int
+	atomsCount   int64
+	typesCount   [typesKindsCount]int
+	triclinic    bool
+	boxLo, boxHi []float64
+	tiltFactors  [3]float64
+}
+
+type _RestartReader struct {
+	reader    *bufio.Reader
+	byteOrder binary.ByteOrder
+	header    _Header
+	masses    []float64
+	labels    [typesKindsCount][]string
+	coeffs    [typesKindsCount][][]float64
+
+	atoms     []structs.Atom
+	bonds     map[[3]int]bool
+	angles    map[[4]int]bool
+	dihedrals map[[5]int]bool
+	impropers map[[5]int]bool
+}
+
+/*
+Read converts a LAMMPS binary restart file into a set of objects
+that represents the system.
+
+Params:
+  - reader: a restart file content
+
+Returns:
+  - LammpsStruct: the system representation
+  - error: any error occured
+*/
+func Read(reader io.Reader) (*structs.LammpsStruct, error) {
+	restart := &_RestartReader{
+		reader:    bufio.NewReader(reader),
+		bonds:     make(map[[3]int]bool),
+		angles:    make(map[[4]int]bool),
+		dihedrals: make(map[[5]int]bool),
+		impropers: make(map[[5]int]bool),
+	}
+	steps := []func() error{
+		restart.readMagic,
+		restart.readHeader,
+		restart.readTypeArrays,
+		restart.readForceFields,
+		restart.readFileLayout,
+		restart.readAtoms,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+	return restart.constructLammpsStruct()
+}
+
+// ================== Primitives ==================
+
+func (restart *_RestartReader) read(data any) error {
+	err := binary.Read(restart.reader, restart.byteOrder, data)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (restart *_RestartReader) readInt() (int32, error) {
+	var value int32
+	err := restart.read(&value)
+	return value, err
+}
+
+func (restart *_RestartReader) readBigint() (int64, error) {
+	var value int64
+	err := restart.read(&value)
+	return value, err
+}
+
+func (restart *_RestartReader) readDouble() (float64, error) {
+	var value float64
+	err := restart.read(&value)
+	return value, err
+}
+
+func (restart *_RestartReader) readInts(count int) ([]int32, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("negative count in the restart file: %d", count)
+	}
+	values := make([]int32, count)
+	err := restart.read(values)
+	return values, err
+}
+
+func (restart *_RestartReader) readDoubles(count int) ([]float64, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("negative count in the restart file: %d", count)
+	}
+	values := make([]float64, count)
+	err := restart.read(values)
+	return values, err
+}
+
+// readString reads the length (with the trailing zero byte) and the characters.
+func (restart *_RestartReader) readString() (string, error) {
+	length, err := restart.readInt()
+	if err != nil {
+		return "", err
+	}
+	if length < 0 {
+		return "", fmt.Errorf("negative string length in the restart file: %d", length)
+	}
+	data := make([]byte, length)
+	if err := restart.read(data); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(data, "\x00")), nil
+}
+
+// ================== Sections ==================
+
+func (restart *_RestartReader) readMagic() error {
+	magic := make([]byte, len(magicString)+1)
+	if _, err := io.ReadFull(restart.reader, magic); err != nil || string(magic[:len(magicString)]) != magicString {
+		return errors.New("not a LAMMPS restart file or the file was written by a version older than 2016")
+	}
+	var order [4]byte
+	if _, err := io.ReadFull(restart.reader, order[:]); err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	if binary.LittleEndian.Uint32(order[:]) == endian {
+		restart.byteOrder = binary.LittleEndian
+	} else if binary.BigEndian.Uint32(order[:]) == endian {
+		restart.byteOrder = binary.BigEndian
+	} else {
+		return errors.New("could not detect the byte order of the restart file")
+	}
+	revision, err := restart.readInt()
+	if err != nil {
+		return err
+	}
+	if revision > formatRevision {
+		return fmt.Errorf("unsupported restart format revision %d (at most %d is supported)", revision, formatRevision)
+	}
+	return nil
+}
+
+func (restart *_RestartReader) readHeader() error {
+	header := &restart.header
+	header.imageIntSize = 4
+	for {
+		flag, err := restart.readInt()
+		if err != nil {
+			return err
+		}
+		if flag < 0 {
+			break
+		}
+		kind, ok := headerKinds[flag]
+		if !ok {
+			return fmt.Errorf("unknown entry %d in the restart header", flag)
+		}
+
+		var intValue int64
+		var doubleValue float64
+		var stringValue string
+		var doubleVector []float64
+		switch kind {
+		case kindInt:
+			var value int32
+			value, err = restart.readInt()
+			intValue = int64(value)
+		case kindBigint:
+			intValue, err = restart.readBigint()
+		case kindDouble:
+			doubleValue, err = restart.readDouble()
+		case kindString:
+			stringValue, err = restart.readString()
+		case kindIntVector, kindDoubleVector:
+			var length int32
+			if length, err = restart.readInt(); err != nil {
+				return err
+			}
+			if kind == kindIntVector {
+				_, err = restart.readInts(int(length))
+			} else {
+				doubleVector, err = restart.readDoubles(int(length))
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		switch flag {
+		case VERSION:
+			header.version = stringValue
+			if err := checkVersion(stringValue); err != nil {
+				return err
+			}
+		case UNITS:
+			header.units = stringValue
+		case IMAGEINT:
+			header.imageIntSize = int(intValue)
+		case ATOM_STYLE:
+			header.atomStyle = stringValue
+			// the style arguments follow the style name
+			argsCount, err := restart.readInt()
+			if err != nil {
+				return err
+			}
+			for i := 0; i < int(argsCount); i++ {
+				if _, err := restart.readString(); err != nil {
+					return err
+				}
+			}
+		case NATOMS:
+			header.atomsCount = intValue
+		case NTYPES:
+			header.typesCount[typeAtom] = int(intValue)
+		case NBONDTYPES:
+			header.typesCount[typeBond] = int(intValue)
+		case NANGLETYPES:
+			header.typesCount[typeAngle] = int(intValue)
+		case NDIHEDRALTYPES:
+			header.typesCount[typeDihedral] = int(intValue)
+		case NIMPROPERTYPES:
+			header.typesCount[typeImproper] = int(intValue)
+		case TRICLINIC:
+			header.triclinic = intValue != 0
+		case BOXLO:
+			header.boxLo = doubleVector
+		case BOXHI:
+			header.boxHi = doubleVector
+		case XY:
+			header.tiltFactors[0] = doubleValue
+		case XZ:
+			header.tiltFactors[1] = doubleValue
+		case YZ:
+			header.tiltFactors[2] = doubleValue
+		}
+	}
+	if len(header.boxLo) != 3 || len(header.boxHi) != 3 {
+		return errors.New("the restart header has no box")
+	}
+	if _, ok := atomStyleFields[header.atomStyle]; !ok {
+		return fmt.Errorf("unsupported atom style %q (supported: atomic, charge, bond, angle, molecular, full)", header.atomStyle)
+	}
+	return nil
+}
+
+// checkVersion rejects the files written before the per-atom layout of the atom styles changed.
+func checkVersion(version string) error {
+	date, err := time.Parse("2 Jan 2006", strings.TrimSpace(strings.Split(version, "-")[0]))
+	if err != nil {
+		// development and patch versions have other formats; they are recent enough
+		return nil
+	}
+	if date.Before(perAtomLayoutDate) {
+		return fmt.Errorf("restart files of LAMMPS %s are not supported, versions since %s are",
+			version, perAtomLayoutDate.Format("2 Jan 2006"))
+	}
+	return nil
+}
+
+func (restart *_RestartReader) readTypeArrays() error {
+	for {
+		flag, err := restart.readInt()
+		if err != nil {
+			return err
+		}
+		if flag < 0 {
+			return nil
+		}
+		switch flag {
+		case MASS:
+			count, err := restart.readInt()
+			if err != nil {
+				return err
+			}
+			if restart.masses, err = restart.readDoubles(int(count)); err != nil {
+				return err
+			}
+		case LABELMAP:
+			if _, err := restart.readInt(); err != nil {
+				return err
+			}
+			for kind := range restart.labels {
+				restart.labels[kind] = make([]string, restart.header.typesCount[kind])
+				for i := range restart.labels[kind] {
+					if restart.labels[kind][i], err = restart.readString(); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			return fmt.Errorf("unknown per-type entry %d in the restart file", flag)
+		}
+	}
+}
+
+func (restart *_RestartReader) readForceFields() error {
+	for {
+		flag, err := restart.readInt()
+		if err != nil {
+			return err
+		}
+		if flag < 0 {
+			return nil
+		}
+		style, err := restart.readString()
+		if err != nil {
+			return err
+		}
+		header := &restart.header
+		switch flag {
+		case PAIR:
+			pairReader, ok := pairStyles[style]
+			if !ok {
+				return fmt.Errorf("unsupported pair style %q: its restart data can not be skipped", style)
+			}
+			err = pairReader(restart, header.typesCount[typeAtom])
+		case BOND:
+			restart.coeffs[typeBond], err = readCoeffs(restart, bondStyles, "bond", style, header.typesCount[typeBond])
+			// BondType keeps two coefficients, so the longer ones would be cut silently
+			if err == nil && len(restart.coeffs[typeBond]) > 0 && len(restart.coeffs[typeBond][0]) > 2 {
+				err = fmt.Errorf("unsupported bond style %q: its %d coefficients per type do not fit the two of a bond type",
+					style, len(restart.coeffs[typeBond][0]))
+			}
+		case ANGLE:
+			restart.coeffs[typeAngle], err = readCoeffs(restart, angleStyles, "angle", style, header.typesCount[typeAngle])
+		case DIHEDRAL:
+			restart.coeffs[typeDihedral], err = readCoeffs(restart, dihedralStyles, "dihedral", style, header.typesCount[typeDihedral])
+		case IMPROPER:
+			restart.coeffs[typeImproper], err = readCoeffs(restart, improperStyles, "improper", style, header.typesCount[typeImproper])
+		default:
+			return fmt.Errorf("unknown force field entry %d in the restart file", flag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readCoeffs(restart *_RestartReader, styles map[string]_CoeffsReader, kind, style string, typesCount int) ([][]float64, error) {
+	coeffsReader, ok := styles[style]
+	if !ok {
+		return nil, fmt.Errorf("unsupported %s style %q: its restart data can not be skipped", kind, style)
+	}
+	return coeffsReader(restart, typesCount)
+}
+
+func (restart *_RestartReader) readFileLayout() error {
+	for {
+		flag, err := restart.readInt()
+		if err != nil {
+			return err
+		}
+		if flag < 0 {
+			return nil
+		}
+		value, err := restart.readInt()
+		if err != nil {
+			return err
+		}
+		switch flag {
+		case MULTIPROC:
+			if value != 0 {
+				return errors.New("restart files split into several files are not supported")
+			}
+		case MPIIO:
+			if value != 0 {
+				return errors.New("restart files written with MPI-IO are not supported")
+			}
+		default:
+			return fmt.Errorf("unknown file layout entry %d in the restart file", flag)
+		}
+	}
+}
+
+// ================== Atoms ==================
+
+func (restart *_RestartReader) readAtoms() error {
+	fields := atomStyleFields[restart.header.atomStyle]
+	for {
+		if _, err := restart.reader.Peek(1); err == io.EOF {
+			break
+		}
+		flag, err := restart.readInt()
+		if err != nil {
+			return err
+		}
+		if flag != PERPROC {
+			return fmt.Errorf("unexpected entry %d instead of the per-processor atoms", flag)
+		}
+		count, err := restart.readInt()
+		if err != nil {
+			return err
+		}
+		values, err := restart.readDoubles(int(count))
+		if err != nil {
+			return err
+		}
+		for offset := 0; offset < len(values); {
+			size := int(values[offset])
+			if size <= 0 || offset+size > len(values) {
+				return errors.New("corrupted per-atom data in the restart file")
+			}
+			if err := restart.parseAtom(&_AtomBuffer{values: values[offset : offset+size], position: 1}, fields); err != nil {
+				return err
+			}
+			offset += size
+		}
+	}
+	if int64(len(restart.atoms)) != restart.header.atomsCount {
+		return fmt.Errorf("the restart file has %d atoms instead of %d", len(restart.atoms), restart.header.atomsCount)
+	}
+	return nil
+}
+
+// _AtomBuffer reads the packed values of a single atom.
+type _AtomBuffer struct {
+	values   []float64
+	position int
+	overflow bool
+}
+
+func (buffer *_AtomBuffer) next() float64 {
+	if buffer.position >= len(buffer.values) {
+		buffer.overflow = true
+		return 0
+	}
+	value := buffer.values[buffer.position]
+	buffer.position++
+	return value
+}
+
+// nextInt reads an integer stored in the bits of a double (the LAMMPS ubuf union).
+func (buffer *_AtomBuffer) nextInt() int {
+	return int(int64(math.Float64bits(buffer.next())))
+}
+
+func (buffer *_AtomBuffer) nextInts(count int) []int {
+	values := make([]int, count)
+	for i := range values {
+		values[i] = buffer.nextInt()
+	}
+	return values
+}
+
+func (restart *_RestartReader) parseAtom(buffer *_AtomBuffer, fields []string) error {
+	atom := structs.Atom{}
+	atom.X, atom.Y, atom.Z = buffer.next(), buffer.next(), buffer.next()
+	atom.AtomID = buffer.nextInt()
+	atom.AtomType = buffer.nextInt()
+	buffer.nextInt() // group mask
+	atom.Image = restart.decodeImage(int64(buffer.nextInt()))
+	buffer.next() // velocities are not kept
+	buffer.next()
+	buffer.next()
+
+	if atom.AtomType >= 1 && atom.AtomType <= len(restart.labels[typeAtom]) {
+		atom.Label = restart.labels[typeAtom][atom.AtomType-1]
+	}
+
+	for _, field := range fields {
+		switch field {
+		case "q":
+			atom.Q = buffer.next()
+		case "molecule":
+			atom.MoleculeID = buffer.nextInt()
+		case "bonds":
+			count := buffer.nextInt()
+			if count < 0 || count > len(buffer.values) {
+				return errors.New("corrupted bonds of an atom in the restart file")
+			}
+			types, partners := buffer.nextInts(count), buffer.nextInts(count)
+			for i := range types {
+				ends := [2]int{atom.AtomID, partners[i]}
+				slices.Sort(ends[:])
+				restart.bonds[[3]int{types[i], ends[0], ends[1]}] = true
+			}
+		case "angles":
+			atoms, err := readTopology(buffer, 3)
+			if err != nil {
+				return err
+			}
+			for _, angle := range atoms {
+				if angle[1] > angle[3] {
+					angle[1], angle[3] = angle[3], angle[1]
+				}
+				restart.angles[[4]int(angle)] = true
+			}
+		case "dihedrals":
+			atoms, err := readTopology(buffer, 4)
+			if err != nil {
+				return err
+			}
+			for _, dihedral := range atoms {
+				if dihedral[1] > dihedral[4] {
+					dihedral[1], dihedral[2], dihedral[3], dihedral[4] = dihedral[4], dihedral[3], dihedral[2], dihedral[1]
+				}
+				restart.dihedrals[[5]int(dihedral)] = true
+			}
+		case "impropers":
+			atoms, err := readTopology(buffer, 4)
+			if err != nil {
+				return err
+			}
+			for _, improper := range atoms {
+				restart.impropers[[5]int(improper)] = true
+			}
+		}
+	}
+	if buffer.overflow {
+		return fmt.Errorf("corrupted data of the atom %d in the restart file", atom.AtomID)
+	}
+	restart.atoms = append(restart.atoms, atom)
+	return nil
+}
+
+// readTopology reads the count, the types and then the columns of the atoms of an atom's angles, dihedrals or impropers.
+// Every returned entry is the type followed by the atom IDs.
+func readTopology(buffer *_AtomBuffer, atomsCount int) ([][]int, error) {
+	count := buffer.nextInt()
+	if count < 0 || count > len(buffer.values) {
+		return nil, errors.New("corrupted topology of an atom in the restart file")
+	}
+	entries := make([][]int, count)
+	for i, entryType := range buffer.nextInts(count) {
+		entries[i] = make([]int, atomsCount+1)
+		entries[i][0] = entryType
+	}
+	for column := 1; column <= atomsCount; column++ {
+		for i := range entries {
+			entries[i][column] = buffer.nextInt()
+		}
+	}
+	return entries, nil
+}
+
+// decodeImage unpacks the image flags the way the IMGBITS constants of LAMMPS define.
+func (restart *_RestartReader) decodeImage(image int64) [3]int {
+	bits := 10
+	if restart.header.imageIntSize == 8 {
+		bits = 21
+	}
+	mask := int64(1)<<bits - 1
+	imageMax := int64(1) << (bits - 1)
+	return [3]int{
+		int(image&mask - imageMax),
+		int(image>>bits&mask - imageMax),
+		int(image>>(2*bits) - imageMax),
+	}
+}
+
+// ================== Construction ==================
+
+func (restart *_RestartReader) constructLammpsStruct() (*structs.LammpsStruct, error) {
+	header := &restart.header
+	lammpsStruct := structs.NewLammpsStruct(0, 0, header.typesCount[typeAtom], header.typesCount[typeBond])
+	lammpsStruct.Units = header.units
+
+	slices.SortFunc(restart.atoms, func(a1, a2 structs.Atom) int { return cmp.Compare(a1.AtomID, a2.AtomID) })
+	lammpsStruct.Atoms = restart.atoms
+
+	for i := range lammpsStruct.AtomTypes {
+		lammpsStruct.AtomTypes[i].AtomType = i + 1
+		if i < len(restart.masses) {
+			lammpsStruct.AtomTypes[i].AtomMass = restart.masses[i]
+		}
+		if i < len(restart.labels[typeAtom]) {
+			lammpsStruct.AtomTypes[i].AtomLabel = restart.labels[typeAtom][i]
+		}
+	}
+	for i := range lammpsStruct.BondTypes {
+		lammpsStruct.BondTypes[i].BondID = i + 1
+		if coeffs := restart.coeffs[typeBond]; i < len(coeffs) && len(coeffs[i]) == 2 {
+			lammpsStruct.BondTypes[i].Sth1 = coeffs[i][0]
+			lammpsStruct.BondTypes[i].Sth2 = coeffs[i][1]
+		}
+	}
+	for i := 0; i < header.typesCount[typeAngle]; i++ {
+		lammpsStruct.AngleTypes = append(lammpsStruct.AngleTypes, structs.AngleType{AngleID: i + 1, Coeffs: coeffsOf(restart.coeffs[typeAngle], i)})
+	}
+	for i := 0; i < header.typesCount[typeDihedral]; i++ {
+		lammpsStruct.DihedralTypes = append(lammpsStruct.DihedralTypes, structs.DihedralType{DihedralID: i + 1, Coeffs: coeffsOf(restart.coeffs[typeDihedral], i)})
+	}
+	for i := 0; i < header.typesCount[typeImproper]; i++ {
+		lammpsStruct.ImproperTypes = append(lammpsStruct.ImproperTypes, structs.ImproperType{ImproperID: i + 1, Coeffs: coeffsOf(restart.coeffs[typeImproper], i)})
+	}
+
+	for i, bond := range sortedKeys(restart.bonds, func(key [3]int) []int { return key[:] }) {
+		lammpsStruct.Bonds = append(lammpsStruct.Bonds, *structs.NewBond(i+1, bond[0], [2]int{bond[1], bond[2]}))
+	}
+	for i, angle := range sortedKeys(restart.angles, func(key [4]int) []int { return key[:] }) {
+		lammpsStruct.Angles = append(lammpsStruct.Angles, *structs.NewAngle(i+1, angle[0], [3]int(angle[1:])))
+	}
+	for i, dihedral := range sortedKeys(restart.dihedrals, func(key [5]int) []int { return key[:] }) {
+		lammpsStruct.Dihedrals = append(lammpsStruct.Dihedrals, *structs.NewDihedral(i+1, dihedral[0], [4]int(dihedral[1:])))
+	}
+	for i, improper := range sortedKeys(restart.impropers, func(key [5]int) []int { return key[:] }) {
+		lammpsStruct.Impropers = append(lammpsStruct.Impropers, *structs.NewImproper(i+1, improper[0], [4]int(improper[1:])))
+	}
+
+	for i := 0; i < 3; i++ {
+		lammpsStruct.SpaceDimention[i][0] = header.boxLo[i]
+		lammpsStruct.SpaceDimention[i][1] = header.boxHi[i]
+	}
+	lammpsStruct.Triclinic = header.triclinic
+	if header.triclinic {
+		lammpsStruct.TiltFactors = header.tiltFactors
+	}
+	return lammpsStruct, nil
+}
+
+func coeffsOf(coeffs [][]float64, index int) []float64 {
+	if index < len(coeffs) {
+		return coeffs[index]
+	}
+	return nil
+}
+
+// sortedKeys orders the topology (type and atom IDs) by the atom IDs first,
+// so the assigned IDs do not depend on the map order.
+func sortedKeys[K comparable](set map[K]bool, values func(K) []int) [][]int {
+	keys := make([][]int, 0, len(set))
+	for key := range set {
+		keys = append(keys, values(key))
+	}
+	slices.SortFunc(keys, func(k1, k2 []int) int {
+		if order := slices.Compare(k1[1:], k2[1:]); order != 0 {
+			return order
+		}
+		return cmp.Compare(k1[0], k2[0])
+	})
+	return keys
+}
@@ -0,0 +1,125 @@
+package restart
+
+import "math"
+
+// _CoeffsReader reads the restart data of a bond, angle, dihedral or improper style
+// and returns the coefficients of every type in the order of the data file sections.
+type _CoeffsReader func(restart *_RestartReader, typesCount int) ([][]float64, error)
+
+// _PairReader skips the restart data of a pair style; pair coefficients are not kept.
+type _PairReader func(restart *_RestartReader, typesCount int) error
+
+// the styles with more than two coefficients are read only to report that they do not fit BondType
+var bondStyles = map[string]_CoeffsReader{
+	"harmonic": doubleArrays(2, nil),
+	"fene":     doubleArrays(4, nil),
+	"morse":    doubleArrays(3, nil),
+}
+
+var angleStyles = map[string]_CoeffsReader{
+	// theta0 is kept in radians
+	"harmonic": doubleArrays(2, []bool{false, true}),
+	"cosine":   doubleArrays(1, nil),
+}
+
+var dihedralStyles = map[string]_CoeffsReader{
+	"harmonic": doubleAndIntArrays,
+}
+
+var improperStyles = map[string]_CoeffsReader{
+	// chi is kept in radians
+	"harmonic": doubleArrays(2, []bool{false, true}),
+	"cvff":     doubleAndIntArrays,
+}
+
+var pairStyles = map[string]_PairReader{
+	"lj/cut": pairCoeffs(
+		[]int{kindDouble, kindInt, kindInt, kindInt}, 3),
+	"lj/cut/coul/long": pairCoeffs(
+		[]int{kindDouble, kindDouble, kindInt, kindInt, kindInt, kindInt, kindDouble}, 3),
+}
+
+/*
+doubleArrays reads the styles storing every coefficient as an array over the types.
+The angles flags mark the coefficients stored in radians that are converted into degrees.
+*/
+func doubleArrays(coeffsCount int, angles []bool) _CoeffsReader {
+	return func(restart *_RestartReader, typesCount int) ([][]float64, error) {
+		coeffs := makeCoeffs(typesCount, coeffsCount)
+		for i := 0; i < coeffsCount; i++ {
+			values, err := restart.readDoubles(typesCount)
+			if err != nil {
+				return nil, err
+			}
+			isAngle := i < len(angles) && angles[i]
+			for t, value := range values {
+				if isAngle {
+					value *= 180 / math.Pi
+				}
+				coeffs[t][i] = value
+			}
+		}
+		return coeffs, nil
+	}
+}
+
+// doubleAndIntArrays reads the styles with the K, d (sign) and n (multiplicity) coefficients.
+func doubleAndIntArrays(restart *_RestartReader, typesCount int) ([][]float64, error) {
+	coeffs := makeCoeffs(typesCount, 3)
+	k, err := restart.readDoubles(typesCount)
+	if err != nil {
+		return nil, err
+	}
+	for t := range k {
+		coeffs[t][0] = k[t]
+	}
+	for i := 1; i < 3; i++ {
+		values, err := restart.readInts(typesCount)
+		if err != nil {
+			return nil, err
+		}
+		for t, value := range values {
+			coeffs[t][i] = float64(value)
+		}
+	}
+	return coeffs, nil
+}
+
+// pairCoeffs skips the global settings and then the per pair flags and coefficients.
+func pairCoeffs(settings []int, coeffsCount int) _PairReader {
+	return func(restart *_RestartReader, typesCount int) error {
+		for _, kind := range settings {
+			var err error
+			if kind == kindInt {
+				_, err = restart.readInt()
+			} else {
+				_, err = restart.readDouble()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for i := 1; i <= typesCount; i++ {
+			for j := i; j <= typesCount; j++ {
+				isSet, err := restart.readInt()
+				if err != nil {
+					return err
+				}
+				if isSet != 0 {
+					if _, err := restart.readDoubles(coeffsCount); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func makeCoeffs(typesCount, coeffsCount int) [][]float64 {
+	coeffs := make([][]float64, typesCount)
+	for t := range coeffs {
+		coeffs[t] = make([]float64, coeffsCount)
+	}
+	return coeffs
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Ivanestver/lammps-file-parser/selection"
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+var selectCommand = _Command{
+	name:        "select",
+	usage:       "-e expression [-ids] [-compact-types] [-map mapping] [-o output] input",
+	description: "Extract the atoms picked by a selection expression, e.g. \"type 1 2 and z > 10\", as a sub-system.",
+	run:         runSelect,
+}
+
+func runSelect(command *_Command, args []string) error {
+	flags := newFlagSet(command)
+	expression := flags.String("e", "", "selection expression")
+	onlyIDs := flags.Bool("ids", false, "print the IDs of the selected atoms instead of the sub-system")
+	compactTypes := flags.Bool("compact-types", false, "keep only the used types and renumber them")
+	mappingFile := flags.String("map", "", "file to write the mapping of the new IDs to the original ones to, as JSON")
+	output := flags.String("o", stdio, "output file")
+	from := flags.String("from", "", "input format: lammps or json (detected by the extension by default)")
+	to := flags.String("to", "", "output format: lammps or json (detected by the extension by default)")
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return usageErrorf("expected one input file")
+	}
+	if len(*expression) == 0 {
+		return usageErrorf("expected a selection expression")
+	}
+	parsed, err := selection.Parse(*expression)
+	if err != nil {
+		return usageErrorf("wrong selection: %s", err.Error())
+	}
+	lammpsStruct, err := readStruct(files[0], *from)
+	if err != nil {
+		return err
+	}
+
+	ids := parsed.Evaluate(lammpsStruct)
+	if *onlyIDs {
+		var builder strings.Builder
+		for _, id := range ids {
+			fmt.Fprintln(&builder, id)
+		}
+		return writeFile([]byte(builder.String()), *output)
+	}
+	subset, mapping, err := lammpsStruct.Subset(ids, structs.SubsetOptions{CompactTypes: *compactTypes})
+	if err != nil {
+		return err
+	}
+	if len(*mappingFile) != 0 {
+		content, err := json.MarshalIndent(mapping, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFile(append(content, '\n'), *mappingFile); err != nil {
+			return err
+		}
+	}
+	return writeStruct(subset, *output, *to)
+}
@@ -0,0 +1,21 @@
+/*
+Package selection picks atoms of a structs.LammpsStruct by VMD-like expressions:
+
+	type 1 2 and z > 10
+	mol 5 to 20
+	label C and within 5 of type 3
+	bonded to id 17
+	not (x < 0 or x >= 40) and q != 0
+
+The keywords id, type and mol take lists of numbers and ranges ("5 to 20" or "5:20"),
+label takes a list of labels. The fields id, type, mol, q (or charge), x, y and z
+may be compared with a number by <, <=, >, >=, == (or =) and !=.
+The selections are combined by and, or, not and parentheses; all and none select
+every atom and no atom.
+
+"within D of S" selects the atoms closer than D to any atom of S (including the atoms
+of S) under the minimum image convention, so the periodic boundaries are honoured.
+"bonded to S" selects the atoms bonded to any atom of S. Like not, both apply to the
+closest selection: "within 5 of type 3 and z > 2" means "(within 5 of type 3) and z > 2".
+*/
+package selection
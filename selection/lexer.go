@@ -0,0 +1,113 @@
+package selection
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type _TokenKind = int
+
+const (
+	tokenWord _TokenKind = iota
+	tokenNumber
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenEnd
+)
+
+type _Token struct {
+	kind     _TokenKind
+	text     string
+	position int
+}
+
+func (token _Token) String() string {
+	if token.kind == tokenEnd {
+		return "the end of the expression"
+	}
+	return fmt.Sprintf("%q at %d", token.text, token.position+1)
+}
+
+// tokenize splits the expression into words, numbers, comparison operators and parentheses.
+func tokenize(expression string) ([]_Token, error) {
+	tokens := make([]_Token, 0)
+	runes := []rune(expression)
+	for position := 0; position < len(runes); {
+		symbol := runes[position]
+		start := position
+		switch {
+		case unicode.IsSpace(symbol):
+			position++
+			continue
+		case symbol == '(':
+			tokens = append(tokens, _Token{kind: tokenOpen, text: "(", position: start})
+			position++
+		case symbol == ')':
+			tokens = append(tokens, _Token{kind: tokenClose, text: ")", position: start})
+			position++
+		case strings.ContainsRune("<>=!", symbol):
+			position++
+			if position < len(runes) && runes[position] == '=' {
+				position++
+			}
+			text := string(runes[start:position])
+			if text == "!" {
+				return nil, fmt.Errorf("unknown operator \"!\" at %d", start+1)
+			}
+			tokens = append(tokens, _Token{kind: tokenOperator, text: text, position: start})
+		case symbol == '"' || symbol == '\'':
+			end := start + 1
+			for end < len(runes) && runes[end] != symbol {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated quote at %d", start+1)
+			}
+			tokens = append(tokens, _Token{kind: tokenWord, text: string(runes[start+1 : end]), position: start})
+			position = end + 1
+		case isNumberStart(runes, position):
+			position = scanNumber(runes, position)
+			tokens = append(tokens, _Token{kind: tokenNumber, text: string(runes[start:position]), position: start})
+		case symbol == ':':
+			tokens = append(tokens, _Token{kind: tokenWord, text: ":", position: start})
+			position++
+		default:
+			for position < len(runes) && !unicode.IsSpace(runes[position]) && !strings.ContainsRune("()<>=!:\"'", runes[position]) {
+				position++
+			}
+			tokens = append(tokens, _Token{kind: tokenWord, text: string(runes[start:position]), position: start})
+		}
+	}
+	return append(tokens, _Token{kind: tokenEnd, position: len(runes)}), nil
+}
+
+func isNumberStart(runes []rune, position int) bool {
+	if runes[position] == '-' || runes[position] == '+' || runes[position] == '.' {
+		position++
+	}
+	return position < len(runes) && unicode.IsDigit(runes[position])
+}
+
+func scanNumber(runes []rune, position int) int {
+	if runes[position] == '-' || runes[position] == '+' {
+		position++
+	}
+	for position < len(runes) && (unicode.IsDigit(runes[position]) || runes[position] == '.') {
+		position++
+	}
+	if position < len(runes) && (runes[position] == 'e' || runes[position] == 'E') {
+		exponent := position + 1
+		if exponent < len(runes) && (runes[exponent] == '-' || runes[exponent] == '+') {
+			exponent++
+		}
+		if exponent < len(runes) && unicode.IsDigit(runes[exponent]) {
+			position = exponent
+			for position < len(runes) && unicode.IsDigit(runes[position]) {
+				position++
+			}
+		}
+	}
+	return position
+}
@@ -0,0 +1,204 @@
+package selection
+
+import (
+	"slices"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+// _Context is the structure a selection is evaluated over.
+type _Context struct {
+	lammpsStruct *structs.LammpsStruct
+	// neighbours are the indices of the bonded atoms, built on the first use
+	neighbours [][]int
+}
+
+func newContext(lammpsStruct *structs.LammpsStruct) *_Context {
+	return &_Context{lammpsStruct: lammpsStruct}
+}
+
+func (context *_Context) atomsCount() int {
+	return len(context.lammpsStruct.Atoms)
+}
+
+func (context *_Context) bondedIndices() [][]int {
+	if context.neighbours != nil {
+		return context.neighbours
+	}
+	atoms := context.lammpsStruct.Atoms
+	indices := make(map[int]int, len(atoms))
+	for i := range atoms {
+		indices[atoms[i].AtomID] = i
+	}
+	context.neighbours = make([][]int, len(atoms))
+	for _, bond := range context.lammpsStruct.Bonds {
+		first, firstFound := indices[bond.Ends[0]]
+		second, secondFound := indices[bond.Ends[1]]
+		if !firstFound || !secondFound {
+			continue
+		}
+		context.neighbours[first] = append(context.neighbours[first], second)
+		context.neighbours[second] = append(context.neighbours[second], first)
+	}
+	return context.neighbours
+}
+
+// _Node is a part of a selection expression; it marks the atoms it selects.
+type _Node interface {
+	evaluate(context *_Context) []bool
+}
+
+type _Constant struct {
+	value bool
+}
+
+func (node *_Constant) evaluate(context *_Context) []bool {
+	mask := make([]bool, context.atomsCount())
+	for i := range mask {
+		mask[i] = node.value
+	}
+	return mask
+}
+
+type _Not struct {
+	operand _Node
+}
+
+func (node *_Not) evaluate(context *_Context) []bool {
+	mask := node.operand.evaluate(context)
+	for i := range mask {
+		mask[i] = !mask[i]
+	}
+	return mask
+}
+
+type _Binary struct {
+	isAnd       bool
+	left, right _Node
+}
+
+func (node *_Binary) evaluate(context *_Context) []bool {
+	left, right := node.left.evaluate(context), node.right.evaluate(context)
+	for i := range left {
+		if node.isAnd {
+			left[i] = left[i] && right[i]
+		} else {
+			left[i] = left[i] || right[i]
+		}
+	}
+	return left
+}
+
+func fieldValue(atom *structs.Atom, field string) float64 {
+	switch field {
+	case "id":
+		return float64(atom.AtomID)
+	case "type":
+		return float64(atom.AtomType)
+	case "mol":
+		return float64(atom.MoleculeID)
+	case "q":
+		return atom.Q
+	case "x":
+		return atom.X
+	case "y":
+		return atom.Y
+	}
+	return atom.Z
+}
+
+// _Ranges selects the atoms with the field within any of the ranges, bounds included.
+type _Ranges struct {
+	field  string
+	ranges [][2]float64
+}
+
+func (node *_Ranges) evaluate(context *_Context) []bool {
+	mask := make([]bool, context.atomsCount())
+	for i := range mask {
+		value := fieldValue(&context.lammpsStruct.Atoms[i], node.field)
+		for _, bounds := range node.ranges {
+			if bounds[0] <= value && value <= bounds[1] {
+				mask[i] = true
+				break
+			}
+		}
+	}
+	return mask
+}
+
+type _Labels struct {
+	labels map[string]bool
+}
+
+func (node *_Labels) evaluate(context *_Context) []bool {
+	mask := make([]bool, context.atomsCount())
+	for i := range mask {
+		mask[i] = node.labels[context.lammpsStruct.Atoms[i].Label]
+	}
+	return mask
+}
+
+type _Comparison struct {
+	field    string
+	operator string
+	value    float64
+}
+
+func (node *_Comparison) evaluate(context *_Context) []bool {
+	mask := make([]bool, context.atomsCount())
+	for i := range mask {
+		value := fieldValue(&context.lammpsStruct.Atoms[i], node.field)
+		switch node.operator {
+		case "<":
+			mask[i] = value < node.value
+		case "<=":
+			mask[i] = value <= node.value
+		case ">":
+			mask[i] = value > node.value
+		case ">=":
+			mask[i] = value >= node.value
+		case "==":
+			mask[i] = value == node.value
+		case "!=":
+			mask[i] = value != node.value
+		}
+	}
+	return mask
+}
+
+// _Within selects the atoms not farther than the distance from the atoms of the operand.
+type _Within struct {
+	distance float64
+	operand  _Node
+}
+
+func (node *_Within) evaluate(context *_Context) []bool {
+	selected := node.operand.evaluate(context)
+	mask := slices.Clone(selected)
+	context.lammpsStruct.Pairs(node.distance, func(first, second int, distance float64) {
+		mask[first] = mask[first] || selected[second]
+		mask[second] = mask[second] || selected[first]
+	})
+	return mask
+}
+
+// _BondedTo selects the atoms bonded to the atoms of the operand.
+type _BondedTo struct {
+	operand _Node
+}
+
+func (node *_BondedTo) evaluate(context *_Context) []bool {
+	selected := node.operand.evaluate(context)
+	neighbours := context.bondedIndices()
+	mask := make([]bool, len(selected))
+	for i := range selected {
+		if !selected[i] {
+			continue
+		}
+		for _, neighbour := range neighbours[i] {
+			mask[neighbour] = true
+		}
+	}
+	return mask
+}
@@ -0,0 +1,245 @@
+package selection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// reservedWords may not be used as labels without quotes.
+var reservedWords = map[string]bool{
+	"and": true, "or": true, "not": true, "within": true, "of": true, "bonded": true, "to": true,
+	"all": true, "none": true, "id": true, "type": true, "mol": true, "label": true,
+	"q": true, "charge": true, "x": true, "y": true, "z": true,
+}
+
+// listFields are the fields taking the lists of numbers and ranges.
+var listFields = map[string]bool{"id": true, "type": true, "mol": true}
+
+// comparedFields are the fields that may be compared with a number.
+var comparedFields = map[string]string{
+	"id": "id", "type": "type", "mol": "mol", "q": "q", "charge": "q", "x": "x", "y": "y", "z": "z",
+}
+
+// flippedOperators turn "10 < z" into "z > 10".
+var flippedOperators = map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<=", "==": "==", "=": "==", "!=": "!="}
+
+type _Parser struct {
+	tokens   []_Token
+	position int
+}
+
+func (parser *_Parser) peek() _Token {
+	return parser.tokens[parser.position]
+}
+
+func (parser *_Parser) next() _Token {
+	token := parser.tokens[parser.position]
+	if token.kind != tokenEnd {
+		parser.position++
+	}
+	return token
+}
+
+// isWord tells if the next token is the given keyword.
+func (parser *_Parser) isWord(word string) bool {
+	token := parser.peek()
+	return token.kind == tokenWord && strings.ToLower(token.text) == word
+}
+
+func (parser *_Parser) expectWord(word string) error {
+	if !parser.isWord(word) {
+		return fmt.Errorf("expected %q, got %s", word, parser.peek())
+	}
+	parser.next()
+	return nil
+}
+
+func (parser *_Parser) number() (float64, error) {
+	token := parser.next()
+	if token.kind != tokenNumber {
+		return 0, fmt.Errorf("expected a number, got %s", token)
+	}
+	value, err := strconv.ParseFloat(token.text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("wrong number %s", token)
+	}
+	return value, nil
+}
+
+// parseOr parses the lowest precedence level: the selections joined by or.
+func (parser *_Parser) parseOr() (_Node, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.isWord("or") {
+		parser.next()
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &_Binary{isAnd: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *_Parser) parseAnd() (_Node, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for parser.isWord("and") {
+		parser.next()
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &_Binary{isAnd: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (parser *_Parser) parseUnary() (_Node, error) {
+	switch {
+	case parser.isWord("not"):
+		parser.next()
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &_Not{operand: operand}, nil
+	case parser.isWord("within"):
+		parser.next()
+		distance, err := parser.number()
+		if err != nil {
+			return nil, err
+		}
+		if distance < 0 {
+			return nil, fmt.Errorf("the distance of within must not be negative, got %g", distance)
+		}
+		if err := parser.expectWord("of"); err != nil {
+			return nil, err
+		}
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &_Within{distance: distance, operand: operand}, nil
+	case parser.isWord("bonded"):
+		parser.next()
+		if err := parser.expectWord("to"); err != nil {
+			return nil, err
+		}
+		operand, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &_BondedTo{operand: operand}, nil
+	}
+	return parser.parsePrimary()
+}
+
+func (parser *_Parser) parsePrimary() (_Node, error) {
+	token := parser.peek()
+	switch token.kind {
+	case tokenOpen:
+		parser.next()
+		node, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := parser.next(); closing.kind != tokenClose {
+			return nil, fmt.Errorf("expected \")\", got %s", closing)
+		}
+		return node, nil
+	case tokenNumber:
+		// a comparison written the other way round, e.g. 10 < z
+		value, err := parser.number()
+		if err != nil {
+			return nil, err
+		}
+		operator := parser.next()
+		if operator.kind != tokenOperator {
+			return nil, fmt.Errorf("expected a comparison operator, got %s", operator)
+		}
+		field := parser.next()
+		name, ok := comparedFields[strings.ToLower(field.text)]
+		if field.kind != tokenWord || !ok {
+			return nil, fmt.Errorf("expected a field to compare, got %s", field)
+		}
+		return &_Comparison{field: name, operator: flippedOperators[operator.text], value: value}, nil
+	case tokenWord:
+	default:
+		return nil, fmt.Errorf("unexpected %s", token)
+	}
+
+	keyword := strings.ToLower(token.text)
+	parser.next()
+	switch keyword {
+	case "all":
+		return &_Constant{value: true}, nil
+	case "none":
+		return &_Constant{value: false}, nil
+	case "label":
+		return parser.parseLabels()
+	}
+	field, ok := comparedFields[keyword]
+	if !ok {
+		return nil, fmt.Errorf("unknown keyword %s", token)
+	}
+	if parser.peek().kind == tokenOperator {
+		operator := parser.next()
+		value, err := parser.number()
+		if err != nil {
+			return nil, err
+		}
+		if operator.text == "=" {
+			operator.text = "=="
+		}
+		return &_Comparison{field: field, operator: operator.text, value: value}, nil
+	}
+	if !listFields[field] {
+		return nil, fmt.Errorf("expected a comparison operator after %s", token)
+	}
+	return parser.parseRanges(field, token)
+}
+
+// parseRanges parses a list like "1 3 5 to 10 12:14".
+func (parser *_Parser) parseRanges(field string, keyword _Token) (_Node, error) {
+	node := &_Ranges{field: field}
+	for parser.peek().kind == tokenNumber {
+		lower, err := parser.number()
+		if err != nil {
+			return nil, err
+		}
+		upper := lower
+		if parser.isWord("to") || parser.isWord(":") {
+			parser.next()
+			if upper, err = parser.number(); err != nil {
+				return nil, err
+			}
+		}
+		node.ranges = append(node.ranges, [2]float64{lower, upper})
+	}
+	if len(node.ranges) == 0 {
+		return nil, fmt.Errorf("expected numbers after %s", keyword)
+	}
+	return node, nil
+}
+
+func (parser *_Parser) parseLabels() (_Node, error) {
+	node := &_Labels{labels: make(map[string]bool)}
+	for {
+		token := parser.peek()
+		if token.kind != tokenWord || reservedWords[strings.ToLower(token.text)] || token.text == ":" {
+			break
+		}
+		node.labels[token.text] = true
+		parser.next()
+	}
+	if len(node.labels) == 0 {
+		return nil, fmt.Errorf("expected labels after \"label\", got %s", parser.peek())
+	}
+	return node, nil
+}
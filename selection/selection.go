@@ -0,0 +1,79 @@
+package selection
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+// Selection is a parsed selection expression; it may be evaluated over any structure.
+type Selection struct {
+	Expression string
+	root       _Node
+}
+
+/*
+Parse parses a selection expression.
+
+Params:
+  - expression: the expression, e.g. "type 1 2 and z > 10"
+
+Returns:
+  - Selection: the parsed selection
+  - error: a syntax error with the position in the expression
+*/
+func Parse(expression string) (*Selection, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	parser := &_Parser{tokens: tokens}
+	if parser.peek().kind == tokenEnd {
+		return nil, fmt.Errorf("empty selection")
+	}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %s", token)
+	}
+	return &Selection{Expression: strings.TrimSpace(expression), root: root}, nil
+}
+
+// Mask returns for every atom of the structure, in the order of Atoms, whether it is selected.
+func (selection *Selection) Mask(lammpsStruct *structs.LammpsStruct) []bool {
+	return selection.root.evaluate(newContext(lammpsStruct))
+}
+
+// Evaluate returns the IDs of the selected atoms in the order of Atoms.
+func (selection *Selection) Evaluate(lammpsStruct *structs.LammpsStruct) []int {
+	mask := selection.Mask(lammpsStruct)
+	ids := make([]int, 0)
+	for i, selected := range mask {
+		if selected {
+			ids = append(ids, lammpsStruct.Atoms[i].AtomID)
+		}
+	}
+	return ids
+}
+
+/*
+Select parses the expression and returns the IDs of the atoms it selects.
+
+Params:
+  - lammpsStruct: the structure to select from
+  - expression: the selection expression
+
+Returns:
+  - []int: the IDs of the selected atoms in the order of Atoms
+  - error: a syntax error
+*/
+func Select(lammpsStruct *structs.LammpsStruct, expression string) ([]int, error) {
+	selection, err := Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	return selection.Evaluate(lammpsStruct), nil
+}
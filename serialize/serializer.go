@@ -2,6 +2,7 @@ package serialize
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Ivanestver/lammps-file-parser/structs"
@@ -48,6 +49,9 @@ func (serializer *_Serializer) Serialize() (string, error) {
 		return "", err
 	}
 	serializer.writeLine("")
+	if err := serializer.serializeTopologyCoeffs(); err != nil {
+		return "", err
+	}
 	if err := serializer.serializeAtoms(); err != nil {
 		return "", err
 	}
@@ -55,6 +59,9 @@ func (serializer *_Serializer) Serialize() (string, error) {
 	if err := serializer.serializeBonds(); err != nil {
 		return "", err
 	}
+	if err := serializer.serializeHigherTopology(); err != nil {
+		return "", err
+	}
 	return serializer.builder.String(), nil
 }
 
@@ -75,6 +82,9 @@ func (serializer *_Serializer) serializeMetadata() error {
 	if err := serializer.serializeBondsTypesCount(); err != nil {
 		return err
 	}
+	if err := serializer.serializeHigherTopologyCounts(); err != nil {
+		return err
+	}
 	serializer.writeLine("")
 	if err := serializer.serializeSpaceMeasures(); err != nil {
 		return err
@@ -84,7 +94,15 @@ func (serializer *_Serializer) serializeMetadata() error {
 
 // ================== Metadata ==================
 func (serializer *_Serializer) serializeHeader() error {
-	_, err := serializer.writeLine("LAMMPS data file via write_data")
+	header := "LAMMPS data file via write_data"
+	if len(serializer.lammpsStruct.Units) != 0 {
+		header += fmt.Sprintf(", units = %s", serializer.lammpsStruct.Units)
+	}
+	// write_data does not record the boundary, so it is kept in the header for the input scripts
+	if serializer.lammpsStruct.Boundary != [3]string{} {
+		header += fmt.Sprintf(", boundary = %s", strings.Join(serializer.lammpsStruct.BoundaryStyles(), " "))
+	}
+	_, err := serializer.writeLine(header)
 	return err
 }
 
@@ -108,6 +126,31 @@ func (serializer *_Serializer) serializeBondsTypesCount() error {
 	return err
 }
 
+// serializeHigherTopologyCounts writes the angles, dihedrals and impropers counts if the structure has any.
+func (serializer *_Serializer) serializeHigherTopologyCounts() error {
+	lammpsStruct := serializer.lammpsStruct
+	counts := []struct {
+		count, typesCount int
+		name              string
+	}{
+		{len(lammpsStruct.Angles), len(lammpsStruct.AngleTypes), "angle"},
+		{len(lammpsStruct.Dihedrals), len(lammpsStruct.DihedralTypes), "dihedral"},
+		{len(lammpsStruct.Impropers), len(lammpsStruct.ImproperTypes), "improper"},
+	}
+	for _, count := range counts {
+		if count.count == 0 && count.typesCount == 0 {
+			continue
+		}
+		if _, err := serializer.writeLinef("%d %ss", count.count, count.name); err != nil {
+			return err
+		}
+		if _, err := serializer.writeLinef("%d %s types", count.typesCount, count.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (serializer *_Serializer) serializeSpaceMeasures() error {
 	axes := [3]rune{'x', 'y', 'z'}
 	for i, axis := range axes {
@@ -118,6 +161,12 @@ func (serializer *_Serializer) serializeSpaceMeasures() error {
 			return err
 		}
 	}
+	if serializer.lammpsStruct.Triclinic {
+		tilt := serializer.lammpsStruct.TiltFactors
+		if _, err := serializer.writeLinef("%f %f %f xy xz yz", tilt[0], tilt[1], tilt[2]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -131,6 +180,12 @@ func (serializer *_Serializer) serializeSpaceMeasure(lower, higher float64, axis
 func (serializer *_Serializer) serializeMasses() error {
 	serializer.writeLine("Masses\n")
 	for _, atomType := range serializer.lammpsStruct.AtomTypes {
+		if len(atomType.AtomLabel) == 0 {
+			if _, err := serializer.writeLinef("%d %f", atomType.AtomType, atomType.AtomMass); err != nil {
+				return err
+			}
+			continue
+		}
 		if _, err := serializer.writeLinef("%d %f # %s", atomType.AtomType, atomType.AtomMass, atomType.AtomLabel); err != nil {
 			return err
 		}
@@ -150,12 +205,61 @@ func (serializer *_Serializer) serializeBondCoeffs() error {
 	return nil
 }
 
+// ================== Angle, dihedral and improper coeffs ==================
+
+// serializeCoeffs writes a coefficients section; it is left out when some types have no coefficients,
+// e.g. the generated ones, so they are to be set in the input script.
+func (serializer *_Serializer) serializeCoeffs(section string, types []int, coeffs [][]float64) error {
+	if len(types) == 0 || slices.ContainsFunc(coeffs, func(typeCoeffs []float64) bool { return len(typeCoeffs) == 0 }) {
+		return nil
+	}
+	serializer.writeLinef("%s\n", section)
+	for i, typeNumber := range types {
+		serializer.writeStringf("%d", typeNumber)
+		for _, coeff := range coeffs[i] {
+			serializer.writeStringf(" %g", coeff)
+		}
+		if _, err := serializer.writeLine(""); err != nil {
+			return err
+		}
+	}
+	_, err := serializer.writeLine("")
+	return err
+}
+
+func (serializer *_Serializer) serializeTopologyCoeffs() error {
+	lammpsStruct := serializer.lammpsStruct
+
+	types, coeffs := make([]int, 0), make([][]float64, 0)
+	for _, angleType := range lammpsStruct.AngleTypes {
+		types, coeffs = append(types, angleType.AngleID), append(coeffs, angleType.Coeffs)
+	}
+	if err := serializer.serializeCoeffs("Angle Coeffs", types, coeffs); err != nil {
+		return err
+	}
+
+	types, coeffs = types[:0], coeffs[:0]
+	for _, dihedralType := range lammpsStruct.DihedralTypes {
+		types, coeffs = append(types, dihedralType.DihedralID), append(coeffs, dihedralType.Coeffs)
+	}
+	if err := serializer.serializeCoeffs("Dihedral Coeffs", types, coeffs); err != nil {
+		return err
+	}
+
+	types, coeffs = types[:0], coeffs[:0]
+	for _, improperType := range lammpsStruct.ImproperTypes {
+		types, coeffs = append(types, improperType.ImproperID), append(coeffs, improperType.Coeffs)
+	}
+	return serializer.serializeCoeffs("Improper Coeffs", types, coeffs)
+}
+
 func (serializer *_Serializer) serializeAtoms() error {
 	serializer.writeLine("Atoms # full\n")
 	for _, atom := range serializer.lammpsStruct.Atoms {
-		if _, err := serializer.writeLinef("%d %d %d %f %f %f %f 0 0 0",
+		if _, err := serializer.writeLinef("%d %d %d %f %f %f %f %d %d %d",
 			atom.AtomID, atom.MoleculeID, atom.AtomType, atom.Q,
 			atom.X, atom.Y, atom.Z,
+			atom.Image[0], atom.Image[1], atom.Image[2],
 		); err != nil {
 			return err
 		}
@@ -174,3 +278,42 @@ func (serializer *_Serializer) serializeBonds() error {
 	}
 	return nil
 }
+
+// ================== Angles, dihedrals and impropers ==================
+
+func (serializer *_Serializer) serializeHigherTopology() error {
+	lammpsStruct := serializer.lammpsStruct
+	if len(lammpsStruct.Angles) != 0 {
+		serializer.writeLine("\nAngles\n")
+		for _, angle := range lammpsStruct.Angles {
+			if _, err := serializer.writeLinef("%d %d %d %d %d",
+				angle.AngleID, angle.AngleType, angle.Atoms[0], angle.Atoms[1], angle.Atoms[2],
+			); err != nil {
+				return err
+			}
+		}
+	}
+	if len(lammpsStruct.Dihedrals) != 0 {
+		serializer.writeLine("\nDihedrals\n")
+		for _, dihedral := range lammpsStruct.Dihedrals {
+			if _, err := serializer.writeLinef("%d %d %d %d %d %d",
+				dihedral.DihedralID, dihedral.DihedralType,
+				dihedral.Atoms[0], dihedral.Atoms[1], dihedral.Atoms[2], dihedral.Atoms[3],
+			); err != nil {
+				return err
+			}
+		}
+	}
+	if len(lammpsStruct.Impropers) != 0 {
+		serializer.writeLine("\nImpropers\n")
+		for _, improper := range lammpsStruct.Impropers {
+			if _, err := serializer.writeLinef("%d %d %d %d %d %d",
+				improper.ImproperID, improper.ImproperType,
+				improper.Atoms[0], improper.Atoms[1], improper.Atoms[2], improper.Atoms[3],
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
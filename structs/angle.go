@@ -0,0 +1,31 @@
+package structs
+
+type Angle struct {
+	AngleID   int
+	AngleType int
+	// Atoms are the IDs of the atoms; the second one is the vertex.
+	Atoms [3]int
+}
+
+func NewAngle(angleID, angleType int, atoms [3]int) *Angle {
+	return &Angle{
+		AngleID:   angleID,
+		AngleType: angleType,
+		Atoms:     atoms,
+	}
+}
+
+func (angle *Angle) Equals(other *Angle) bool {
+	if other == nil {
+		return false
+	}
+	return angle.AngleID == other.AngleID &&
+		angle.AngleType == other.AngleType &&
+		angle.equalAtoms(other)
+}
+
+func (angle *Angle) equalAtoms(other *Angle) bool {
+	return angle.Atoms[1] == other.Atoms[1] &&
+		((angle.Atoms[0] == other.Atoms[0] && angle.Atoms[2] == other.Atoms[2]) ||
+			(angle.Atoms[0] == other.Atoms[2] && angle.Atoms[2] == other.Atoms[0]))
+}
@@ -15,6 +15,8 @@ type Atom struct {
 	AtomType   int
 	Q          float64
 	AtomCoords
+	// Image holds the image flags: how many times the atom crossed the periodic box along each axis.
+	Image [3]int
 }
 
 func NewAtom(label string, atomID, moleculeID, atomType int, q, x, y, z float64) *Atom {
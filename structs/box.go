@@ -0,0 +1,207 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type BoundaryStyle = string
+
+const (
+	BOUNDARY_PERIODIC BoundaryStyle = "p"
+	BOUNDARY_FIXED    BoundaryStyle = "f"
+	// BOUNDARY_SHRINK makes the face follow the atoms wherever they move
+	BOUNDARY_SHRINK BoundaryStyle = "s"
+	// BOUNDARY_SHRINK_MIN makes the face follow the atoms, but not inside the initial box
+	BOUNDARY_SHRINK_MIN BoundaryStyle = "m"
+)
+
+/*
+SetBoundary records the boundary styles per axis, like the LAMMPS "boundary" command.
+
+Params:
+  - styles: the styles along x, y and z: "p", "f", "s" or "m", or two of the last three for
+    the lower and the upper faces, e.g. "fs"; "p" cannot be mixed with the others
+
+Returns:
+  - error: a wrong style; the boundary is not changed then
+*/
+func (lammpsStruct *LammpsStruct) SetBoundary(styles [3]string) error {
+	for axis, style := range styles {
+		if err := checkBoundaryStyle(style); err != nil {
+			return fmt.Errorf("the %c axis: %w", 'x'+axis, err)
+		}
+	}
+	lammpsStruct.Boundary = styles
+	return nil
+}
+
+// BoundaryStyles returns the boundary styles per axis; the unknown ones are periodic, the LAMMPS default.
+func (lammpsStruct *LammpsStruct) BoundaryStyles() []string {
+	styles := make([]string, len(lammpsStruct.Boundary))
+	for axis, style := range lammpsStruct.Boundary {
+		styles[axis] = style
+		if len(style) == 0 {
+			styles[axis] = BOUNDARY_PERIODIC
+		}
+	}
+	return styles
+}
+
+// BoundaryCommand returns the "boundary" command of an input script for the structure, e.g. "boundary p p f".
+func (lammpsStruct *LammpsStruct) BoundaryCommand() string {
+	return "boundary " + strings.Join(lammpsStruct.BoundaryStyles(), " ")
+}
+
+func checkBoundaryStyle(style string) error {
+	if len(style) == 0 || style == BOUNDARY_PERIODIC {
+		return nil
+	}
+	if len(style) > 2 {
+		return fmt.Errorf("wrong boundary style %q", style)
+	}
+	for _, face := range style {
+		switch string(face) {
+		case BOUNDARY_FIXED, BOUNDARY_SHRINK, BOUNDARY_SHRINK_MIN:
+		case BOUNDARY_PERIODIC:
+			return fmt.Errorf("the periodic boundary must be on both faces, got %q", style)
+		default:
+			return fmt.Errorf("wrong boundary style %q", style)
+		}
+	}
+	return nil
+}
+
+/*
+ShrinkWrap fits the box tightly around the unwrapped atoms with a padding on every side,
+which is the box of a molecule in a vacuum. The atoms are unwrapped by their image flags first,
+so the flags are cleared.
+
+Params:
+  - padding: the distance between the outermost atoms and the faces of the box
+
+Returns:
+  - error: the box is triclinic or there are no atoms
+*/
+func (lammpsStruct *LammpsStruct) ShrinkWrap(padding float64) error {
+	if lammpsStruct.Triclinic {
+		return errors.New("a triclinic box cannot be shrink-wrapped; convert it to an orthogonal one first")
+	}
+	if len(lammpsStruct.Atoms) == 0 {
+		return errors.New("there are no atoms to wrap the box around")
+	}
+	lammpsStruct.Unwrap()
+	var box [3][2]float64
+	for i, atom := range lammpsStruct.Atoms {
+		for axis, coord := range [3]float64{atom.X, atom.Y, atom.Z} {
+			if i == 0 || coord < box[axis][0] {
+				box[axis][0] = coord
+			}
+			if i == 0 || coord > box[axis][1] {
+				box[axis][1] = coord
+			}
+		}
+	}
+	for axis := range box {
+		box[axis][0] -= padding
+		box[axis][1] += padding
+		// a flat arrangement of atoms still needs a box of some thickness
+		if box[axis][1] <= box[axis][0] {
+			box[axis][0], box[axis][1] = box[axis][0]-0.5, box[axis][1]+0.5
+		}
+	}
+	lammpsStruct.SpaceDimention = box
+	return nil
+}
+
+/*
+Resize sets new bounds of the box.
+
+Params:
+  - box: the new bounds in the SpaceDimention layout
+  - remap: if set, the atoms are stretched with the box keeping their fractional coordinates and
+    image flags and the tilt factors are stretched too, like "change_box ... remap"; otherwise the
+    unwrapped atoms stay where they are and are wrapped into the new box
+
+Returns:
+  - error: a bound pair with the lower bound not less than the upper one, or a remap of a box
+    with no length along an axis, which has no fractional coordinates to keep
+*/
+func (lammpsStruct *LammpsStruct) Resize(box [3][2]float64, remap bool) error {
+	for axis, bounds := range box {
+		if bounds[0] >= bounds[1] {
+			return fmt.Errorf("the lower bound %g of the %c axis must be less than the upper one %g", bounds[0], 'x'+axis, bounds[1])
+		}
+	}
+	if remap {
+		for axis, length := range boxLengths(lammpsStruct.SpaceDimention) {
+			if !(length > 0) {
+				return fmt.Errorf("the box has no length along the %c axis, so the atoms cannot be stretched with it", 'x'+axis)
+			}
+		}
+	}
+	if !remap {
+		lammpsStruct.Unwrap()
+		lammpsStruct.SpaceDimention = box
+		lammpsStruct.Wrap()
+		return nil
+	}
+
+	fractionals := make([][3]float64, len(lammpsStruct.Atoms))
+	for i := range lammpsStruct.Atoms {
+		fractionals[i] = lammpsStruct.fractional(lammpsStruct.Atoms[i].AtomCoords)
+	}
+	oldLengths, newLengths := boxLengths(lammpsStruct.SpaceDimention), boxLengths(box)
+	lammpsStruct.SpaceDimention = box
+	lammpsStruct.TiltFactors[0] *= newLengths[0] / oldLengths[0]
+	lammpsStruct.TiltFactors[1] *= newLengths[0] / oldLengths[0]
+	lammpsStruct.TiltFactors[2] *= newLengths[1] / oldLengths[1]
+	for i := range lammpsStruct.Atoms {
+		lammpsStruct.Atoms[i].AtomCoords = lammpsStruct.fromFractional(fractionals[i])
+	}
+	return nil
+}
+
+// ToTriclinic makes the box triclinic with zero tilt factors, so it may be tilted later.
+func (lammpsStruct *LammpsStruct) ToTriclinic() {
+	if !lammpsStruct.Triclinic {
+		lammpsStruct.Triclinic = true
+		lammpsStruct.TiltFactors = [3]float64{}
+	}
+}
+
+/*
+ToOrthogonal makes the box orthogonal if its periodic lattice is orthogonal: the tilt factors
+are reduced by whole box edges, so e.g. a tilt xy equal to the length along x is removed.
+The unwrapped atoms stay where they are and are wrapped into the new box.
+
+Returns:
+  - error: the lattice is not orthogonal; the box is not changed then
+*/
+func (lammpsStruct *LammpsStruct) ToOrthogonal() error {
+	if !lammpsStruct.Triclinic {
+		return nil
+	}
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	xy, xz, yz := lammpsStruct.TiltFactors[0], lammpsStruct.TiltFactors[1], lammpsStruct.TiltFactors[2]
+	// c -= n b, then c -= m a and b -= k a
+	if lengths[1] > 0 {
+		periods := math.Round(yz / lengths[1])
+		yz, xz = yz-periods*lengths[1], xz-periods*xy
+	}
+	if lengths[0] > 0 {
+		xz -= math.Round(xz/lengths[0]) * lengths[0]
+		xy -= math.Round(xy/lengths[0]) * lengths[0]
+	}
+	const tolerance = 1e-9
+	if math.Abs(xy) > tolerance*lengths[0] || math.Abs(xz) > tolerance*lengths[0] || math.Abs(yz) > tolerance*lengths[1] {
+		return fmt.Errorf("the box is not orthogonal: the tilt factors reduce to %g %g %g", xy, xz, yz)
+	}
+	lammpsStruct.Unwrap()
+	lammpsStruct.Triclinic = false
+	lammpsStruct.TiltFactors = [3]float64{}
+	lammpsStruct.Wrap()
+	return nil
+}
@@ -0,0 +1,218 @@
+package structs
+
+import (
+	"math"
+	"slices"
+)
+
+/*
+Pairs finds the pairs of the atoms not farther than the cutoff under the minimum image convention.
+The search takes a time linear in the number of atoms: the atoms are binned into cells not thinner
+than the cutoff and only the neighbouring cells are searched. With a cutoff larger than a third of
+the box there are too few cells and all the pairs are checked, still by their nearest images only.
+A structure without a box, e.g. imported from an XYZ file, is searched in a linear time as well:
+along an axis of no length the box is not periodic and the cells cover the extent of the atoms.
+
+Params:
+  - cutoff: the largest distance
+  - visit: gets the indices of the atoms in the Atoms slice, the first one being smaller, and the
+    distance; it is called once for every pair
+*/
+func (lammpsStruct *LammpsStruct) Pairs(cutoff float64, visit func(first, second int, distance float64)) {
+	newCellList(lammpsStruct, cutoff).pairs(visit)
+}
+
+/*
+_CellList bins the atoms into the cells of the periodic box not thinner than the cutoff,
+so the pairs closer than the cutoff are searched only among the neighbouring cells.
+The cells are made in the fractional coordinates, so they follow a triclinic box.
+An axis of the box with no length, e.g. of a structure imported without a box, is not
+periodic: the cells cover the extent of the atoms along it and do not wrap around.
+*/
+type _CellList struct {
+	lammpsStruct *LammpsStruct
+	cutoff       float64
+	counts       [3]int
+	// open marks the axes without a length; lower and widths are the extent of the atoms and the cell widths along them
+	open   [3]bool
+	lower  [3]float64
+	widths [3]float64
+	// cells hold the indices of the atoms in the Atoms slice
+	cells [][]int
+}
+
+func newCellList(lammpsStruct *LammpsStruct, cutoff float64) *_CellList {
+	cellList := &_CellList{lammpsStruct: lammpsStruct, cutoff: cutoff}
+	widths := lammpsStruct.BoxWidths()
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	for axis := range cellList.counts {
+		cellList.open[axis] = !(lengths[axis] > 0)
+	}
+	var upper [3]float64
+	for i := range lammpsStruct.Atoms {
+		position := cellList.position(lammpsStruct.Atoms[i].AtomCoords)
+		for axis, open := range cellList.open {
+			if open && (i == 0 || position[axis] < cellList.lower[axis]) {
+				cellList.lower[axis] = position[axis]
+			}
+			if open && (i == 0 || position[axis] > upper[axis]) {
+				upper[axis] = position[axis]
+			}
+		}
+	}
+	for axis := range cellList.counts {
+		cellList.counts[axis] = 1
+		if cellList.open[axis] {
+			// the cells do not wrap around, so any number of them is fine
+			if count := int((upper[axis] - cellList.lower[axis]) / cutoff); cutoff > 0 && count >= 1 {
+				cellList.counts[axis] = count
+			}
+			continue
+		}
+		// fewer than three cells would make a cell its own neighbour
+		if count := int(widths[axis] / cutoff); cutoff > 0 && count >= 3 {
+			cellList.counts[axis] = count
+		}
+	}
+	// a dilute system would have more cells than atoms, so the cells are made larger
+	if total := float64(cellList.counts[0] * cellList.counts[1] * cellList.counts[2]); total > float64(len(lammpsStruct.Atoms)+1) {
+		scale := math.Cbrt(total / float64(len(lammpsStruct.Atoms)+1))
+		for axis := range cellList.counts {
+			if count := int(float64(cellList.counts[axis]) / scale); count >= 3 || (cellList.open[axis] && count >= 1) {
+				cellList.counts[axis] = count
+			} else {
+				cellList.counts[axis] = 1
+			}
+		}
+	}
+	for axis, open := range cellList.open {
+		if open {
+			cellList.widths[axis] = (upper[axis] - cellList.lower[axis]) / float64(cellList.counts[axis])
+		}
+	}
+	cellList.cells = make([][]int, cellList.counts[0]*cellList.counts[1]*cellList.counts[2])
+	for i := range lammpsStruct.Atoms {
+		cell := cellList.cellOf(cellList.position(lammpsStruct.Atoms[i].AtomCoords))
+		cellList.cells[cell] = append(cellList.cells[cell], i)
+	}
+	return cellList
+}
+
+// position returns the fractional coordinates of a point along the periodic axes and its plain coordinates along the open ones.
+func (cellList *_CellList) position(coords AtomCoords) [3]float64 {
+	box := cellList.lammpsStruct
+	if cellList.open == [3]bool{} {
+		return box.fractional(coords)
+	}
+	lengths := boxLengths(box.SpaceDimention)
+	xy, xz, yz := 0.0, 0.0, 0.0
+	if box.Triclinic {
+		xy, xz, yz = box.TiltFactors[0], box.TiltFactors[1], box.TiltFactors[2]
+	}
+	// an open axis does not tilt the others
+	if cellList.open[2] {
+		xz, yz = 0, 0
+	}
+	if cellList.open[1] {
+		xy = 0
+	}
+	var result, fractional [3]float64
+	for axis, coord := range [3]float64{coords.X, coords.Y, coords.Z} {
+		result[axis] = coord
+	}
+	for axis := 2; axis >= 0; axis-- {
+		if cellList.open[axis] {
+			continue
+		}
+		shift := 0.0
+		switch axis {
+		case 1:
+			shift = fractional[2] * yz
+		case 0:
+			shift = fractional[1]*xy + fractional[2]*xz
+		}
+		fractional[axis] = (result[axis] - box.SpaceDimention[axis][0] - shift) / lengths[axis]
+		result[axis] = fractional[axis]
+	}
+	return result
+}
+
+// cellOf returns the index of the cell with the point given by position.
+func (cellList *_CellList) cellOf(position [3]float64) int {
+	var cell [3]int
+	for axis := range cell {
+		if cellList.counts[axis] == 1 {
+			continue
+		}
+		if cellList.open[axis] {
+			cell[axis] = min(int((position[axis]-cellList.lower[axis])/cellList.widths[axis]), cellList.counts[axis]-1)
+			continue
+		}
+		fraction := position[axis] - math.Floor(position[axis])
+		cell[axis] = min(int(fraction*float64(cellList.counts[axis])), cellList.counts[axis]-1)
+	}
+	return cellList.index(cell)
+}
+
+func (cellList *_CellList) index(cell [3]int) int {
+	return (cell[2]*cellList.counts[1]+cell[1])*cellList.counts[0] + cell[0]
+}
+
+/*
+pairs calls the visit function once for every pair of atoms not farther than the cutoff under
+the minimum image convention.
+
+Params:
+  - visit: gets the indices of the atoms in the Atoms slice, the first one being smaller, and the distance
+*/
+func (cellList *_CellList) pairs(visit func(first, second int, distance float64)) {
+	atoms := cellList.lammpsStruct.Atoms
+	for z := 0; z < cellList.counts[2]; z++ {
+		for y := 0; y < cellList.counts[1]; y++ {
+			for x := 0; x < cellList.counts[0]; x++ {
+				current := cellList.index([3]int{x, y, z})
+				for _, neighbour := range cellList.neighbourCells([3]int{x, y, z}) {
+					if neighbour < current {
+						continue
+					}
+					for _, first := range cellList.cells[current] {
+						for _, second := range cellList.cells[neighbour] {
+							if neighbour == current && second <= first {
+								continue
+							}
+							distance := cellList.lammpsStruct.Distance(&atoms[first].AtomCoords, &atoms[second].AtomCoords)
+							if distance > cellList.cutoff {
+								continue
+							}
+							visit(min(first, second), max(first, second), distance)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// neighbourCells returns the distinct cells around the cell including itself, wrapped periodically along the periodic axes.
+func (cellList *_CellList) neighbourCells(cell [3]int) []int {
+	neighbours := make([]int, 0, 27)
+	for dz := -1; dz <= 1; dz++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				neighbour := [3]int{cell[0] + dx, cell[1] + dy, cell[2] + dz}
+				inside := true
+				for axis := range neighbour {
+					if cellList.open[axis] {
+						inside = inside && neighbour[axis] >= 0 && neighbour[axis] < cellList.counts[axis]
+					} else {
+						neighbour[axis] = floorMod(neighbour[axis], cellList.counts[axis])
+					}
+				}
+				if index := cellList.index(neighbour); inside && !slices.Contains(neighbours, index) {
+					neighbours = append(neighbours, index)
+				}
+			}
+		}
+	}
+	return neighbours
+}
@@ -0,0 +1,137 @@
+package structs
+
+import (
+	"slices"
+)
+
+// DeleteOptions set what DeleteAtoms and DeleteMolecules remove besides the given atoms.
+type DeleteOptions struct {
+	// WholeMolecules deletes the whole molecules of the given atoms, like `mol yes` of delete_atoms.
+	// The atoms without a molecule (ID 0) are deleted alone.
+	WholeMolecules bool
+	// Renumber renumbers the remaining atoms, molecules, bonds, angles, dihedrals and impropers
+	// from 1 in the order of their IDs, like `compress yes` of delete_atoms.
+	Renumber bool
+}
+
+// DeleteReport lists what was removed by DeleteAtoms or DeleteMolecules; the IDs are the original ones.
+type DeleteReport struct {
+	Atoms     []int
+	Molecules []int
+	Bonds     []int
+	Angles    []int
+	Dihedrals []int
+	Impropers []int
+	// Mapping maps the new IDs to the original ones; it is filled only when the IDs are renumbered
+	Mapping *SubsetMapping `json:",omitempty"`
+}
+
+/*
+DeleteAtoms removes the given atoms from the structure together with the bonds, angles,
+dihedrals and impropers they are part of. The types are kept. The IDs of missing atoms are ignored.
+
+Params:
+  - ids: the IDs of the atoms to delete
+  - options: whether to delete the whole molecules and to renumber the rest
+
+Returns:
+  - DeleteReport: the removed atoms, molecules and topology
+*/
+func (lammpsStruct *LammpsStruct) DeleteAtoms(ids []int, options DeleteOptions) *DeleteReport {
+	deleted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	if options.WholeMolecules {
+		molecules := make(map[int]bool)
+		for _, atom := range lammpsStruct.Atoms {
+			if deleted[atom.AtomID] && atom.MoleculeID != 0 {
+				molecules[atom.MoleculeID] = true
+			}
+		}
+		for _, atom := range lammpsStruct.Atoms {
+			if molecules[atom.MoleculeID] {
+				deleted[atom.AtomID] = true
+			}
+		}
+	}
+	return lammpsStruct.deleteAtoms(deleted, options.Renumber)
+}
+
+/*
+DeleteMolecules removes all the atoms of the given molecules from the structure together with
+the bonds, angles, dihedrals and impropers they are part of. The types are kept.
+
+Params:
+  - moleculeIDs: the IDs of the molecules to delete
+  - options: whether to renumber the rest; WholeMolecules has no effect
+
+Returns:
+  - DeleteReport: the removed atoms, molecules and topology
+*/
+func (lammpsStruct *LammpsStruct) DeleteMolecules(moleculeIDs []int, options DeleteOptions) *DeleteReport {
+	molecules := make(map[int]bool, len(moleculeIDs))
+	for _, id := range moleculeIDs {
+		molecules[id] = true
+	}
+	deleted := make(map[int]bool)
+	for _, atom := range lammpsStruct.Atoms {
+		if molecules[atom.MoleculeID] {
+			deleted[atom.AtomID] = true
+		}
+	}
+	return lammpsStruct.deleteAtoms(deleted, options.Renumber)
+}
+
+func (lammpsStruct *LammpsStruct) deleteAtoms(deleted map[int]bool, renumber bool) *DeleteReport {
+	kept := make(map[int]bool, len(lammpsStruct.Atoms))
+	for _, atom := range lammpsStruct.Atoms {
+		if !deleted[atom.AtomID] {
+			kept[atom.AtomID] = true
+		}
+	}
+	result := lammpsStruct.withAtoms(kept)
+
+	report := &DeleteReport{}
+	remaining := make(map[int]bool)
+	for _, atom := range result.Atoms {
+		remaining[atom.MoleculeID] = true
+	}
+	molecules := make(map[int]bool)
+	for _, atom := range lammpsStruct.Atoms {
+		if kept[atom.AtomID] {
+			continue
+		}
+		report.Atoms = append(report.Atoms, atom.AtomID)
+		if atom.MoleculeID != 0 && !remaining[atom.MoleculeID] && !molecules[atom.MoleculeID] {
+			molecules[atom.MoleculeID] = true
+			report.Molecules = append(report.Molecules, atom.MoleculeID)
+		}
+	}
+	report.Bonds = removedIDs(lammpsStruct.Bonds, result.Bonds, func(bond *Bond) int { return bond.BondID })
+	report.Angles = removedIDs(lammpsStruct.Angles, result.Angles, func(angle *Angle) int { return angle.AngleID })
+	report.Dihedrals = removedIDs(lammpsStruct.Dihedrals, result.Dihedrals, func(dihedral *Dihedral) int { return dihedral.DihedralID })
+	report.Impropers = removedIDs(lammpsStruct.Impropers, result.Impropers, func(improper *Improper) int { return improper.ImproperID })
+	slices.Sort(report.Molecules)
+
+	if renumber {
+		report.Mapping = result.renumberIDs()
+	}
+	// the header counts of the file do not describe the structure anymore
+	*lammpsStruct = *result
+	return report
+}
+
+// removedIDs returns the IDs of the items that are not in the rest; the rest keeps the order of the items.
+func removedIDs[T any](items, rest []T, id func(*T) int) []int {
+	var removed []int
+	j := 0
+	for i := range items {
+		if j < len(rest) && id(&items[i]) == id(&rest[j]) {
+			j++
+			continue
+		}
+		removed = append(removed, id(&items[i]))
+	}
+	return removed
+}
@@ -0,0 +1,233 @@
+package structs
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+type ChangeKind = string
+
+const (
+	CHANGE_ADDED   ChangeKind = "added"
+	CHANGE_REMOVED ChangeKind = "removed"
+	CHANGE_CHANGED ChangeKind = "changed"
+)
+
+// valueTolerance is the relative difference of masses, charges and coefficients considered equal.
+const valueTolerance = 1e-9
+
+/*
+Change is a difference between two structures. Old and New are the item
+as a line of the data file section in the first and the second structure;
+Old is empty for the added items and New is empty for the removed ones.
+*/
+type Change struct {
+	Kind    ChangeKind
+	Section string
+	// ID is the ID of the item in the first structure, or in the second one for the added items.
+	ID  int
+	Old string
+	New string
+}
+
+/*
+Diff compares two structures. Atoms and types are matched by their IDs,
+while bonds, angles, dihedrals and impropers are matched by their atoms,
+in either order where Equals allows it, so renumbering them is not a change;
+a different type of the same connection is.
+
+Params:
+  - first: the old structure
+  - second: the new structure
+  - tolerance: the largest difference of the coordinates considered equal
+
+Returns:
+  - []Change: the changes in the order of the data file sections; empty for equal structures
+*/
+func Diff(first, second *LammpsStruct, tolerance float64) []Change {
+	changes := make([]Change, 0)
+	if first.Units != second.Units {
+		changes = append(changes, Change{Kind: CHANGE_CHANGED, Section: "Header",
+			Old: "units = " + first.Units, New: "units = " + second.Units})
+	}
+	if oldBox, newBox := formatBox(first), formatBox(second); oldBox != newBox {
+		changes = append(changes, Change{Kind: CHANGE_CHANGED, Section: "Box", Old: oldBox, New: newBox})
+	}
+
+	changes = append(changes, diffByID("Masses", first.AtomTypes, second.AtomTypes,
+		func(atomType AtomType) int { return atomType.AtomType },
+		func(old, updated AtomType) bool {
+			return old.AtomLabel == updated.AtomLabel && equalValues(old.AtomMass, updated.AtomMass, 0)
+		},
+		func(atomType AtomType) string {
+			return strings.TrimSpace(fmt.Sprintf("%d %g %s", atomType.AtomType, atomType.AtomMass, atomType.AtomLabel))
+		})...)
+	changes = append(changes, diffByID("Bond Coeffs", first.BondTypes, second.BondTypes,
+		func(bondType BondType) int { return bondType.BondID },
+		func(old, updated BondType) bool {
+			return equalValues(old.Sth1, updated.Sth1, 0) && equalValues(old.Sth2, updated.Sth2, 0)
+		},
+		func(bondType BondType) string {
+			return fmt.Sprintf("%d %g %g", bondType.BondID, bondType.Sth1, bondType.Sth2)
+		})...)
+	changes = append(changes, diffByID("Angle Coeffs", first.AngleTypes, second.AngleTypes,
+		func(angleType AngleType) int { return angleType.AngleID },
+		func(old, updated AngleType) bool { return equalCoeffs(old.Coeffs, updated.Coeffs) },
+		func(angleType AngleType) string { return formatCoeffs(angleType.AngleID, angleType.Coeffs) })...)
+	changes = append(changes, diffByID("Dihedral Coeffs", first.DihedralTypes, second.DihedralTypes,
+		func(dihedralType DihedralType) int { return dihedralType.DihedralID },
+		func(old, updated DihedralType) bool { return equalCoeffs(old.Coeffs, updated.Coeffs) },
+		func(dihedralType DihedralType) string {
+			return formatCoeffs(dihedralType.DihedralID, dihedralType.Coeffs)
+		})...)
+	changes = append(changes, diffByID("Improper Coeffs", first.ImproperTypes, second.ImproperTypes,
+		func(improperType ImproperType) int { return improperType.ImproperID },
+		func(old, updated ImproperType) bool { return equalCoeffs(old.Coeffs, updated.Coeffs) },
+		func(improperType ImproperType) string {
+			return formatCoeffs(improperType.ImproperID, improperType.Coeffs)
+		})...)
+
+	changes = append(changes, diffByID("Atoms", first.Atoms, second.Atoms,
+		func(atom Atom) int { return atom.AtomID },
+		func(old, updated Atom) bool {
+			return old.Label == updated.Label &&
+				old.MoleculeID == updated.MoleculeID &&
+				old.AtomType == updated.AtomType &&
+				old.Image == updated.Image &&
+				equalValues(old.Q, updated.Q, 0) &&
+				equalValues(old.X, updated.X, tolerance) &&
+				equalValues(old.Y, updated.Y, tolerance) &&
+				equalValues(old.Z, updated.Z, tolerance)
+		},
+		func(atom Atom) string {
+			return fmt.Sprintf("%d %d %d %g %g %g %g %d %d %d", atom.AtomID, atom.MoleculeID, atom.AtomType, atom.Q,
+				atom.X, atom.Y, atom.Z, atom.Image[0], atom.Image[1], atom.Image[2])
+		})...)
+
+	changes = append(changes, diffTopology("Bonds", first.Bonds, second.Bonds,
+		func(bond Bond) (int, int, []int) {
+			return bond.BondID, bond.ConnectionType, []int{min(bond.Ends[0], bond.Ends[1]), max(bond.Ends[0], bond.Ends[1])}
+		})...)
+	changes = append(changes, diffTopology("Angles", first.Angles, second.Angles,
+		func(angle Angle) (int, int, []int) { return angle.AngleID, angle.AngleType, reversible(angle.Atoms[:]) })...)
+	changes = append(changes, diffTopology("Dihedrals", first.Dihedrals, second.Dihedrals,
+		func(dihedral Dihedral) (int, int, []int) {
+			return dihedral.DihedralID, dihedral.DihedralType, reversible(dihedral.Atoms[:])
+		})...)
+	changes = append(changes, diffTopology("Impropers", first.Impropers, second.Impropers,
+		func(improper Improper) (int, int, []int) {
+			return improper.ImproperID, improper.ImproperType, improper.Atoms[:]
+		})...)
+	return changes
+}
+
+// diffByID matches the items by their IDs; the changes are sorted by the IDs.
+func diffByID[T any](section string, first, second []T, id func(T) int, equal func(T, T) bool, format func(T) string) []Change {
+	changes := make([]Change, 0)
+	newItems := make(map[int]T, len(second))
+	for _, item := range second {
+		newItems[id(item)] = item
+	}
+	oldIDs := make(map[int]bool, len(first))
+	for _, old := range first {
+		oldIDs[id(old)] = true
+		updated, ok := newItems[id(old)]
+		if !ok {
+			changes = append(changes, Change{Kind: CHANGE_REMOVED, Section: section, ID: id(old), Old: format(old)})
+		} else if !equal(old, updated) {
+			changes = append(changes, Change{Kind: CHANGE_CHANGED, Section: section, ID: id(old), Old: format(old), New: format(updated)})
+		}
+	}
+	for _, updated := range second {
+		if !oldIDs[id(updated)] {
+			changes = append(changes, Change{Kind: CHANGE_ADDED, Section: section, ID: id(updated), New: format(updated)})
+		}
+	}
+	slices.SortStableFunc(changes, func(c1, c2 Change) int { return c1.ID - c2.ID })
+	return changes
+}
+
+/*
+diffTopology matches the bonds, angles, dihedrals or impropers by their atoms.
+The describe function returns the ID, the type and the atoms in the normalized order.
+*/
+func diffTopology[T any](section string, first, second []T, describe func(T) (int, int, []int)) []Change {
+	changes := make([]Change, 0)
+	format := func(id, topologyType int, atoms []int) string {
+		return fmt.Sprintf("%d %d %s", id, topologyType, strings.Trim(fmt.Sprint(atoms), "[]"))
+	}
+	type _Item struct {
+		id, topologyType int
+		atoms            []int
+	}
+	newItems := make(map[string]_Item, len(second))
+	for _, item := range second {
+		id, topologyType, atoms := describe(item)
+		newItems[fmt.Sprint(atoms)] = _Item{id, topologyType, atoms}
+	}
+	oldKeys := make(map[string]bool, len(first))
+	for _, item := range first {
+		id, topologyType, atoms := describe(item)
+		key := fmt.Sprint(atoms)
+		oldKeys[key] = true
+		updated, ok := newItems[key]
+		if !ok {
+			changes = append(changes, Change{Kind: CHANGE_REMOVED, Section: section, ID: id, Old: format(id, topologyType, atoms)})
+		} else if updated.topologyType != topologyType {
+			changes = append(changes, Change{Kind: CHANGE_CHANGED, Section: section, ID: id,
+				Old: format(id, topologyType, atoms), New: format(updated.id, updated.topologyType, updated.atoms)})
+		}
+	}
+	for _, item := range second {
+		id, topologyType, atoms := describe(item)
+		if !oldKeys[fmt.Sprint(atoms)] {
+			changes = append(changes, Change{Kind: CHANGE_ADDED, Section: section, ID: id, New: format(id, topologyType, atoms)})
+		}
+	}
+	return changes
+}
+
+// reversible returns the atoms of an angle or a dihedral in the order that does not depend on the direction.
+func reversible(atoms []int) []int {
+	reversed := slices.Clone(atoms)
+	slices.Reverse(reversed)
+	if slices.Compare(reversed, atoms) < 0 {
+		return reversed
+	}
+	return atoms
+}
+
+func equalValues(first, second, tolerance float64) bool {
+	if tolerance > 0 {
+		return math.Abs(first-second) <= tolerance
+	}
+	return math.Abs(first-second) <= valueTolerance*max(math.Abs(first), math.Abs(second))
+}
+
+func equalCoeffs(first, second []float64) bool {
+	return slices.EqualFunc(first, second, func(c1, c2 float64) bool { return equalValues(c1, c2, 0) })
+}
+
+func formatCoeffs(id int, coeffs []float64) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d", id)
+	for _, coeff := range coeffs {
+		fmt.Fprintf(&builder, " %g", coeff)
+	}
+	return builder.String()
+}
+
+func formatBox(lammpsStruct *LammpsStruct) string {
+	box := lammpsStruct.SpaceDimention
+	text := fmt.Sprintf("%g %g xlo xhi, %g %g ylo yhi, %g %g zlo zhi", box[0][0], box[0][1], box[1][0], box[1][1], box[2][0], box[2][1])
+	if lammpsStruct.Triclinic {
+		tilt := lammpsStruct.TiltFactors
+		text += fmt.Sprintf(", %g %g %g xy xz yz", tilt[0], tilt[1], tilt[2])
+	}
+	if lammpsStruct.Boundary != [3]string{} {
+		text += ", " + lammpsStruct.BoundaryCommand()
+	}
+	return text
+}
@@ -0,0 +1,33 @@
+package structs
+
+type Dihedral struct {
+	DihedralID   int
+	DihedralType int
+	Atoms        [4]int
+}
+
+func NewDihedral(dihedralID, dihedralType int, atoms [4]int) *Dihedral {
+	return &Dihedral{
+		DihedralID:   dihedralID,
+		DihedralType: dihedralType,
+		Atoms:        atoms,
+	}
+}
+
+func (dihedral *Dihedral) Equals(other *Dihedral) bool {
+	if other == nil {
+		return false
+	}
+	return dihedral.DihedralID == other.DihedralID &&
+		dihedral.DihedralType == other.DihedralType &&
+		dihedral.equalAtoms(other)
+}
+
+func (dihedral *Dihedral) equalAtoms(other *Dihedral) bool {
+	straight, reversed := true, true
+	for i := range dihedral.Atoms {
+		straight = straight && dihedral.Atoms[i] == other.Atoms[i]
+		reversed = reversed && dihedral.Atoms[i] == other.Atoms[len(other.Atoms)-1-i]
+	}
+	return straight || reversed
+}
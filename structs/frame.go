@@ -0,0 +1,97 @@
+package structs
+
+import "math"
+
+/*
+Frame is a single snapshot of a trajectory. Coordinates and the box
+use the same representation as LammpsStruct, so a frame may be laid
+over the atoms of a data file (the i-th coordinate belongs to the i-th atom).
+*/
+type Frame struct {
+	Timestep int
+	Time     float64
+	Units    string
+	// AtomIDs, AtomTypes and Labels are filled by the formats that store them, e.g. dumps.
+	AtomIDs        []int
+	AtomTypes      []int
+	Labels         []string
+	Coords         []AtomCoords
+	Velocities     []AtomCoords
+	SpaceDimention [3][2]float64
+	Triclinic      bool
+	TiltFactors    [3]float64 // xy, xz, yz
+	// Properties holds the other per-atom values of the frame by their names.
+	Properties map[string][]float64
+}
+
+/*
+BoxFromCell converts the crystallographic cell description (a, b, c and
+alpha, beta, gamma in degrees) into a LAMMPS box with its lower corner
+placed at the origin.
+
+Returns:
+  - the box bounds in the SpaceDimention layout
+  - the tilt factors xy, xz, yz
+  - true if the cell is not orthogonal
+*/
+func BoxFromCell(lengths, angles [3]float64) ([3][2]float64, [3]float64, bool) {
+	cosAlpha := cosDegrees(angles[0])
+	cosBeta := cosDegrees(angles[1])
+	cosGamma := cosDegrees(angles[2])
+
+	lx := lengths[0]
+	xy := lengths[1] * cosGamma
+	xz := lengths[2] * cosBeta
+	ly := math.Sqrt(lengths[1]*lengths[1] - xy*xy)
+	var yz float64
+	if ly != 0 {
+		yz = (lengths[1]*lengths[2]*cosAlpha - xy*xz) / ly
+	}
+	lz := math.Sqrt(math.Max(lengths[2]*lengths[2]-xz*xz-yz*yz, 0))
+
+	var box [3][2]float64
+	box[DIMENTION_TYPE_X][1] = lx
+	box[DIMENTION_TYPE_Y][1] = ly
+	box[DIMENTION_TYPE_Z][1] = lz
+	tilt := [3]float64{xy, xz, yz}
+	return box, tilt, xy != 0 || xz != 0 || yz != 0
+}
+
+/*
+CellFromBox is the inverse of BoxFromCell: it returns the cell edge
+lengths a, b, c and the angles alpha, beta, gamma in degrees.
+*/
+func CellFromBox(spaceDimention [3][2]float64, tiltFactors [3]float64) ([3]float64, [3]float64) {
+	lx := spaceDimention[DIMENTION_TYPE_X][1] - spaceDimention[DIMENTION_TYPE_X][0]
+	ly := spaceDimention[DIMENTION_TYPE_Y][1] - spaceDimention[DIMENTION_TYPE_Y][0]
+	lz := spaceDimention[DIMENTION_TYPE_Z][1] - spaceDimention[DIMENTION_TYPE_Z][0]
+	xy, xz, yz := tiltFactors[0], tiltFactors[1], tiltFactors[2]
+
+	a := lx
+	b := math.Sqrt(ly*ly + xy*xy)
+	c := math.Sqrt(lz*lz + xz*xz + yz*yz)
+	angles := [3]float64{90, 90, 90}
+	if b != 0 && c != 0 {
+		angles[0] = acosDegrees((xy*xz + ly*yz) / (b * c))
+	}
+	if a != 0 && c != 0 {
+		angles[1] = acosDegrees(xz / c)
+	}
+	if a != 0 && b != 0 {
+		angles[2] = acosDegrees(xy / b)
+	}
+	return [3]float64{a, b, c}, angles
+}
+
+func cosDegrees(angle float64) float64 {
+	cos := math.Cos(angle * math.Pi / 180)
+	if math.Abs(cos) < 1e-12 {
+		// keep orthogonal cells exactly orthogonal
+		return 0
+	}
+	return cos
+}
+
+func acosDegrees(cos float64) float64 {
+	return math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+}
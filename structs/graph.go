@@ -0,0 +1,247 @@
+package structs
+
+import (
+	"fmt"
+	"slices"
+)
+
+/*
+Graph is the bond graph of a structure: the atoms are the vertices and the bonds are the edges.
+The atoms are given by their IDs. The bonds to missing atoms, the bonds of an atom to itself and
+the repeated bonds are ignored. The graph does not follow later changes of the structure.
+*/
+type Graph struct {
+	ids        []int
+	indices    map[int]int
+	neighbours [][]int
+}
+
+// Graph builds the bond graph of the structure.
+func (lammpsStruct *LammpsStruct) Graph() *Graph {
+	graph := &Graph{
+		ids:        make([]int, len(lammpsStruct.Atoms)),
+		indices:    make(map[int]int, len(lammpsStruct.Atoms)),
+		neighbours: make([][]int, len(lammpsStruct.Atoms)),
+	}
+	for i, atom := range lammpsStruct.Atoms {
+		graph.ids[i] = atom.AtomID
+		graph.indices[atom.AtomID] = i
+	}
+	for _, bond := range lammpsStruct.Bonds {
+		first, firstFound := graph.indices[bond.Ends[0]]
+		second, secondFound := graph.indices[bond.Ends[1]]
+		if !firstFound || !secondFound || first == second || slices.Contains(graph.neighbours[first], second) {
+			continue
+		}
+		graph.neighbours[first] = append(graph.neighbours[first], second)
+		graph.neighbours[second] = append(graph.neighbours[second], first)
+	}
+	for i := range graph.neighbours {
+		slices.SortFunc(graph.neighbours[i], func(n1, n2 int) int { return graph.ids[n1] - graph.ids[n2] })
+	}
+	return graph
+}
+
+// Neighbours returns the IDs of the atoms bonded to the atom in the ascending order, or nil for a missing atom.
+func (graph *Graph) Neighbours(id int) []int {
+	index, ok := graph.indices[id]
+	if !ok {
+		return nil
+	}
+	return graph.toIDs(graph.neighbours[index])
+}
+
+// Degree returns the number of the atoms bonded to the atom.
+func (graph *Graph) Degree(id int) int {
+	index, ok := graph.indices[id]
+	if !ok {
+		return 0
+	}
+	return len(graph.neighbours[index])
+}
+
+/*
+Components returns the connected components of the graph, that is, the molecules by the bonds.
+An atom without bonds is a component by itself.
+
+Returns:
+  - [][]int: the IDs of the atoms of each component in the ascending order; the components are
+    ordered by their smallest IDs
+*/
+func (graph *Graph) Components() [][]int {
+	components := make([][]int, 0)
+	visited := make([]bool, len(graph.ids))
+	for start := range graph.ids {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		component := []int{start}
+		for i := 0; i < len(component); i++ {
+			for _, neighbour := range graph.neighbours[component[i]] {
+				if !visited[neighbour] {
+					visited[neighbour] = true
+					component = append(component, neighbour)
+				}
+			}
+		}
+		ids := graph.toIDs(component)
+		slices.Sort(ids)
+		components = append(components, ids)
+	}
+	slices.SortFunc(components, func(c1, c2 []int) int { return c1[0] - c2[0] })
+	return components
+}
+
+/*
+ShortestPath finds a path with the fewest bonds between two atoms.
+
+Params:
+  - from: the ID of the first atom
+  - to: the ID of the last atom
+
+Returns:
+  - []int: the IDs of the atoms along the path including both ends, or nil if the atoms are
+    not connected or missing
+*/
+func (graph *Graph) ShortestPath(from, to int) []int {
+	start, startFound := graph.indices[from]
+	end, endFound := graph.indices[to]
+	if !startFound || !endFound {
+		return nil
+	}
+	path := graph.path(start, end, -1, -1, 0)
+	if path == nil {
+		return nil
+	}
+	return graph.toIDs(path)
+}
+
+/*
+Rings finds the rings of the graph: for each bond the smallest ring passing through it.
+For the usual molecules these are the rings a chemist would name, e.g. the two six-membered
+rings of naphthalene and not the ten-membered one around them. When several smallest rings pass
+through a bond, one of them is taken, so the five faces of a cube out of six are found.
+
+Params:
+  - maxSize: the largest ring size in atoms to look for; 0 means no limit
+
+Returns:
+  - [][]int: the IDs of the atoms of each ring in the order along it, starting from the smallest ID
+    towards its smaller neighbour; the rings are ordered by size and then by the IDs
+*/
+func (graph *Graph) Rings(maxSize int) [][]int {
+	rings := make([][]int, 0)
+	if maxSize > 0 && maxSize < 3 {
+		return rings
+	}
+	found := make(map[string]bool)
+	for first := range graph.neighbours {
+		for _, second := range graph.neighbours[first] {
+			if second < first {
+				continue
+			}
+			// a ring of maxSize atoms closes the bond by a path of maxSize-1 bonds
+			path := graph.path(second, first, first, second, max(maxSize-1, 0))
+			if path == nil {
+				continue
+			}
+			ring := graph.canonicalRing(path)
+			if key := fmt.Sprint(ring); !found[key] {
+				found[key] = true
+				rings = append(rings, ring)
+			}
+		}
+	}
+	slices.SortFunc(rings, func(r1, r2 []int) int {
+		if len(r1) != len(r2) {
+			return len(r1) - len(r2)
+		}
+		return slices.Compare(r1, r2)
+	})
+	return rings
+}
+
+/*
+AssignMolecules sets the molecule IDs of the atoms from the connected components of the bonds.
+The molecules are numbered from 1 in the order of their smallest atom IDs; an atom without bonds
+gets a molecule of its own.
+
+Returns:
+  - int: the number of the molecules
+*/
+func (lammpsStruct *LammpsStruct) AssignMolecules() int {
+	components := lammpsStruct.Graph().Components()
+	molecules := make(map[int]int, len(lammpsStruct.Atoms))
+	for i, component := range components {
+		for _, id := range component {
+			molecules[id] = i + 1
+		}
+	}
+	for i := range lammpsStruct.Atoms {
+		lammpsStruct.Atoms[i].MoleculeID = molecules[lammpsStruct.Atoms[i].AtomID]
+	}
+	return len(components)
+}
+
+/*
+path finds a path with the fewest bonds between two vertices by a breadth-first search.
+The bond between the vertices skipFirst and skipSecond is not used. The search keeps to the
+vertices it reaches, so a short path is found in a large graph without touching all of it.
+
+Params:
+  - maxBonds: the longest path in bonds to look for; 0 means no limit
+
+Returns:
+  - []int: the vertices along the path, or nil if there is none
+*/
+func (graph *Graph) path(start, end, skipFirst, skipSecond, maxBonds int) []int {
+	// previous and depths hold the reached vertices only
+	previous := map[int]int{start: start}
+	depths := map[int]int{start: 0}
+	queue := []int{start}
+	for i := 0; i < len(queue); i++ {
+		vertex := queue[i]
+		if _, reached := previous[end]; reached || (maxBonds > 0 && depths[vertex] >= maxBonds) {
+			break
+		}
+		for _, neighbour := range graph.neighbours[vertex] {
+			if _, reached := previous[neighbour]; reached ||
+				(vertex == skipFirst && neighbour == skipSecond) || (vertex == skipSecond && neighbour == skipFirst) {
+				continue
+			}
+			previous[neighbour] = vertex
+			depths[neighbour] = depths[vertex] + 1
+			queue = append(queue, neighbour)
+		}
+	}
+	if _, reached := previous[end]; !reached {
+		return nil
+	}
+	path := []int{end}
+	for vertex := end; vertex != start; {
+		vertex = previous[vertex]
+		path = append(path, vertex)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// canonicalRing turns the vertices of a ring into the IDs starting from the smallest one towards its smaller neighbour.
+func (graph *Graph) canonicalRing(path []int) []int {
+	ring := graph.toIDs(path)
+	smallest := slices.Index(ring, slices.Min(ring))
+	ring = slices.Concat(ring[smallest:], ring[:smallest])
+	if len(ring) > 2 && ring[len(ring)-1] < ring[1] {
+		slices.Reverse(ring[1:])
+	}
+	return ring
+}
+
+func (graph *Graph) toIDs(vertices []int) []int {
+	ids := make([]int, len(vertices))
+	for i, vertex := range vertices {
+		ids[i] = graph.ids[vertex]
+	}
+	return ids
+}
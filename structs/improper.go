@@ -0,0 +1,25 @@
+package structs
+
+type Improper struct {
+	ImproperID   int
+	ImproperType int
+	// Atoms are the IDs of the atoms in the order the improper style expects.
+	Atoms [4]int
+}
+
+func NewImproper(improperID, improperType int, atoms [4]int) *Improper {
+	return &Improper{
+		ImproperID:   improperID,
+		ImproperType: improperType,
+		Atoms:        atoms,
+	}
+}
+
+func (improper *Improper) Equals(other *Improper) bool {
+	if other == nil {
+		return false
+	}
+	return improper.ImproperID == other.ImproperID &&
+		improper.ImproperType == other.ImproperType &&
+		improper.Atoms == other.Atoms
+}
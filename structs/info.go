@@ -0,0 +1,164 @@
+package structs
+
+/*
+densityFactors convert mass / volume in the units of a unit style into g/cm³.
+The lj style has no physical units, so the density is not computed for it.
+*/
+var densityFactors = map[string]float64{
+	"real":     1.66053906660,   // g/mol and Å
+	"metal":    1.66053906660,   // g/mol and Å
+	"si":       1e-3,            // kg and m
+	"cgs":      1,               // g and cm
+	"electron": 11.205871918835, // amu and Bohr
+	"micro":    1,               // pg and µm
+	"nano":     1e3,             // ag and nm
+}
+
+// Statistics summarises a set of values.
+type Statistics struct {
+	Count int
+	Min   float64
+	Mean  float64
+	Max   float64
+}
+
+func (statistics *Statistics) add(value float64) {
+	if statistics.Count == 0 || value < statistics.Min {
+		statistics.Min = value
+	}
+	if statistics.Count == 0 || value > statistics.Max {
+		statistics.Max = value
+	}
+	statistics.Mean += (value - statistics.Mean) / float64(statistics.Count+1)
+	statistics.Count++
+}
+
+// Info is the summary of a LammpsStruct.
+type Info struct {
+	Units string
+
+	AtomsCount     int
+	BondsCount     int
+	AnglesCount    int
+	DihedralsCount int
+	ImpropersCount int
+	// the counts per type number
+	AtomsPerType     map[int]int
+	BondsPerType     map[int]int
+	AnglesPerType    map[int]int
+	DihedralsPerType map[int]int
+	ImpropersPerType map[int]int
+	// Composition counts the atoms by their labels
+	Composition map[string]int
+
+	TotalMass   float64
+	TotalCharge float64
+	Volume      float64
+	// Density is in g/cm³; it is zero when the unit style is unknown or lj
+	Density float64
+
+	// MoleculesCount does not count the atoms with the molecule ID 0, which belong to no molecule
+	MoleculesCount int
+	// MoleculeSizes maps a molecule size in atoms to the number of such molecules
+	MoleculeSizes map[int]int
+	// BondLengths are measured under the minimum image convention
+	BondLengths map[int]Statistics
+	// MaxSpecials are the largest numbers of the 1-2, 1-3 and 1-4 neighbours of an atom and of all of them
+	MaxSpecials [4]int
+
+	SpaceDimention [3][2]float64
+	// Extents are the lowest and the highest coordinates of the atoms along the axes
+	Extents [3][2]float64
+}
+
+/*
+Info computes the summary statistics of the structure.
+
+Params:
+  - units: the unit style for the density; the Units of the structure are used if it is empty
+
+Returns:
+  - Info: the summary
+*/
+func (lammpsStruct *LammpsStruct) Info(units string) *Info {
+	if len(units) == 0 {
+		units = lammpsStruct.Units
+	}
+	info := &Info{
+		Units:            units,
+		AtomsCount:       len(lammpsStruct.Atoms),
+		BondsCount:       len(lammpsStruct.Bonds),
+		AnglesCount:      len(lammpsStruct.Angles),
+		DihedralsCount:   len(lammpsStruct.Dihedrals),
+		ImpropersCount:   len(lammpsStruct.Impropers),
+		AtomsPerType:     make(map[int]int),
+		BondsPerType:     make(map[int]int),
+		AnglesPerType:    make(map[int]int),
+		DihedralsPerType: make(map[int]int),
+		ImpropersPerType: make(map[int]int),
+		Composition:      make(map[string]int),
+		MoleculeSizes:    make(map[int]int),
+		BondLengths:      make(map[int]Statistics),
+		SpaceDimention:   lammpsStruct.SpaceDimention,
+		Volume:           lammpsStruct.boxVolume(),
+	}
+
+	masses := make(map[int]float64, len(lammpsStruct.AtomTypes))
+	for _, atomType := range lammpsStruct.AtomTypes {
+		masses[atomType.AtomType] = atomType.AtomMass
+	}
+	molecules := make(map[int]int)
+	atoms := make(map[int]*Atom, len(lammpsStruct.Atoms))
+	for i := range lammpsStruct.Atoms {
+		atom := &lammpsStruct.Atoms[i]
+		atoms[atom.AtomID] = atom
+		info.AtomsPerType[atom.AtomType]++
+		info.Composition[atom.Label]++
+		info.TotalMass += masses[atom.AtomType]
+		info.TotalCharge += atom.Q
+		if atom.MoleculeID != 0 {
+			molecules[atom.MoleculeID]++
+		}
+		coords := [3]float64{atom.X, atom.Y, atom.Z}
+		for axis, coord := range coords {
+			if i == 0 || coord < info.Extents[axis][0] {
+				info.Extents[axis][0] = coord
+			}
+			if i == 0 || coord > info.Extents[axis][1] {
+				info.Extents[axis][1] = coord
+			}
+		}
+	}
+	info.MoleculesCount = len(molecules)
+	for _, size := range molecules {
+		info.MoleculeSizes[size]++
+	}
+	if factor, ok := densityFactors[units]; ok && info.Volume > 0 {
+		info.Density = info.TotalMass / info.Volume * factor
+	}
+
+	for _, bond := range lammpsStruct.Bonds {
+		info.BondsPerType[bond.ConnectionType]++
+		first, second := atoms[bond.Ends[0]], atoms[bond.Ends[1]]
+		if first == nil || second == nil {
+			continue
+		}
+		statistics := info.BondLengths[bond.ConnectionType]
+		statistics.add(lammpsStruct.Distance(&first.AtomCoords, &second.AtomCoords))
+		info.BondLengths[bond.ConnectionType] = statistics
+	}
+	for _, angle := range lammpsStruct.Angles {
+		info.AnglesPerType[angle.AngleType]++
+	}
+	for _, dihedral := range lammpsStruct.Dihedrals {
+		info.DihedralsPerType[dihedral.DihedralType]++
+	}
+	for _, improper := range lammpsStruct.Impropers {
+		info.ImpropersPerType[improper.ImproperType]++
+	}
+	if len(lammpsStruct.Bonds) != 0 {
+		specials := lammpsStruct.Specials()
+		info.MaxSpecials = [4]int{specials.MaxOneTwo, specials.MaxOneThree, specials.MaxOneFour, specials.MaxSpecial}
+	}
+	return info
+}
@@ -27,6 +27,22 @@ type BondType struct {
 	Sth2   float64
 }
 
+// AngleType, DihedralType and ImproperType keep the coefficients in the order of the "* Coeffs" sections.
+type AngleType struct {
+	AngleID int
+	Coeffs  []float64
+}
+
+type DihedralType struct {
+	DihedralID int
+	Coeffs     []float64
+}
+
+type ImproperType struct {
+	ImproperID int
+	Coeffs     []float64
+}
+
 const (
 	DIMENTION_TYPE_X DimentionType = iota
 	DIMENTION_TYPE_Y
@@ -35,11 +51,27 @@ const (
 
 type LammpsStruct struct {
 	FileName       string
+	Units          string
 	Atoms          []Atom
 	AtomTypes      []AtomType
 	Bonds          []Bond
 	BondTypes      []BondType
+	Angles         []Angle
+	AngleTypes     []AngleType
+	Dihedrals      []Dihedral
+	DihedralTypes  []DihedralType
+	Impropers      []Improper
+	ImproperTypes  []ImproperType
 	SpaceDimention [3][2]float64
+	// Triclinic is set when the box has the "xy xz yz" line.
+	Triclinic   bool
+	TiltFactors [3]float64 // xy, xz, yz
+	// Boundary holds the boundary styles of the "boundary" command per axis, e.g. "p" or "fs";
+	// it is a record for the input scripts and empty when unknown
+	Boundary [3]string
+	// HeaderCounts holds the counts declared in the header of a loaded data file, e.g. "atoms" or "bond types",
+	// so they can be checked against the sections. It is not a part of the JSON representation.
+	HeaderCounts map[string]int `json:"-"`
 }
 
 func NewLammpsStruct(atomsCount, bondsCount, atomsTypesCount, bondsTypesCount int) *LammpsStruct {
@@ -51,17 +83,6 @@ func NewLammpsStruct(atomsCount, bondsCount, atomsTypesCount, bondsTypesCount in
 	return obj
 }
 
-type _Atoms []*Atom
-
-func (atoms _Atoms) setAtom(atom *Atom) {
-	atomId := atom.AtomID - 1
-	if atomId < 0 || atomId >= len(atoms) {
-		panic(fmt.Sprintf("The atom's (%f, %f, %f) Atom ID (%d) is out of bounds (max: %d)",
-			atom.X, atom.Y, atom.Z, atom.AtomID, len(atoms)))
-	}
-	atoms[atom.AtomID-1] = atom
-}
-
 type _MiddleAtom struct {
 	Mass  float64
 	Label string
@@ -72,15 +93,31 @@ type _MiddleBond struct {
 }
 
 type _LammpsMetadata struct {
-	atomsCount     int
-	atomTypesCount int
-	bondsCount     int
-	bondTypesCount int
-	spaceDimention [3][2]float64
-	atomTypes      map[string]_MiddleAtom
-	bondTypes      map[string]_MiddleBond
-	atoms          _Atoms
-	bonds          []*Bond
+	units              string
+	boundary           [3]string
+	atomsCount         int
+	atomTypesCount     int
+	bondsCount         int
+	bondTypesCount     int
+	anglesCount        int
+	angleTypesCount    int
+	dihedralsCount     int
+	dihedralTypesCount int
+	impropersCount     int
+	improperTypesCount int
+	spaceDimention     [3][2]float64
+	triclinic          bool
+	tiltFactors        [3]float64
+	atomTypes          map[string]_MiddleAtom
+	bondTypes          map[string]_MiddleBond
+	angleTypes         map[string][]float64
+	dihedralTypes      map[string][]float64
+	improperTypes      map[string][]float64
+	atoms              []*Atom
+	bonds              []*Bond
+	angles             []*Angle
+	dihedrals          []*Dihedral
+	impropers          []*Improper
 }
 
 type LammpsLoader struct {
@@ -110,31 +147,52 @@ func (loader *LammpsLoader) Load(content string) (*LammpsStruct, error) {
 	return loader.builtGlobula, nil
 }
 
+// sectionName returns the line without the trailing comment, e.g. "Atoms" for "Atoms # full".
+func sectionName(line string) string {
+	if comment := strings.Index(line, "#"); comment >= 0 {
+		line = line[:comment]
+	}
+	return strings.TrimSpace(line)
+}
+
+// scanSectionLine moves to the next line of a section; a section ends with an empty line.
+func (loader *LammpsLoader) scanSectionLine() bool {
+	return loader.scanner.Scan() && len(strings.TrimSpace(loader.scanner.Text())) != 0
+}
+
 func (loader *LammpsLoader) load() error {
 	if err := loader.loadMetadata(); err != nil {
 		return err
 	}
 	for loader.scanner.Scan() {
-		txt := scannerText(loader.scanner.Text())
-		if strings.Contains(txt, "Masses") {
-			if err := loader.loadMasses(); err != nil {
-				return err
-			}
-		} else if strings.Contains(txt, "Bond Coeffs") {
-			if err := loader.loadBondTypes(); err != nil {
-				return err
-			}
-		} else if strings.Contains(txt, "Atoms") {
-			if err := loader.loadAtoms(); err != nil {
-				return err
-			}
-		} else if strings.Contains(txt, "Bonds") {
-			if err := loader.loadBonds(); err != nil {
-				return err
-			}
-		} else {
+		var err error
+		switch sectionName(scannerText(loader.scanner.Text())) {
+		case "Masses":
+			err = loader.loadMasses()
+		case "Bond Coeffs":
+			err = loader.loadBondTypes()
+		case "Angle Coeffs":
+			err = loader.loadCoeffs("Angle Coeffs", loader.angleTypes)
+		case "Dihedral Coeffs":
+			err = loader.loadCoeffs("Dihedral Coeffs", loader.dihedralTypes)
+		case "Improper Coeffs":
+			err = loader.loadCoeffs("Improper Coeffs", loader.improperTypes)
+		case "Atoms":
+			err = loader.loadAtoms()
+		case "Bonds":
+			err = loader.loadBonds()
+		case "Angles":
+			err = loader.loadAngles()
+		case "Dihedrals":
+			err = loader.loadDihedrals()
+		case "Impropers":
+			err = loader.loadImpropers()
+		default:
 			continue
 		}
+		if err != nil {
+			return err
+		}
 	}
 	if err := loader.constructLammpsStruct(); err != nil {
 		return err
@@ -143,11 +201,14 @@ func (loader *LammpsLoader) load() error {
 }
 
 func (loader *LammpsLoader) loadMetadata() error {
+	loader.units = readUnits(loader.content)
+	loader.boundary = readBoundary(loader.content)
+
 	// read atoms section
 	if err := readMetadata(loader, &loader.atomsCount, "atoms"); err != nil {
 		return err
 	}
-	loader.atoms = make([]*Atom, loader.atomsCount)
+	loader.atoms = make([]*Atom, 0, loader.atomsCount)
 
 	// read atoms types section
 	if err := readMetadata(loader, &loader.atomTypesCount, "atom types"); err != nil {
@@ -166,6 +227,27 @@ func (loader *LammpsLoader) loadMetadata() error {
 		return err
 	}
 
+	// read the optional sections of the higher order topology
+	optionalSections := []struct {
+		metadata    *int
+		sectionName string
+	}{
+		{&loader.anglesCount, "angles"},
+		{&loader.angleTypesCount, "angle types"},
+		{&loader.dihedralsCount, "dihedrals"},
+		{&loader.dihedralTypesCount, "dihedral types"},
+		{&loader.impropersCount, "impropers"},
+		{&loader.improperTypesCount, "improper types"},
+	}
+	for _, section := range optionalSections {
+		if err := readOptionalMetadata(loader, section.metadata, section.sectionName); err != nil {
+			return err
+		}
+	}
+	loader.angleTypes = make(map[string][]float64)
+	loader.dihedralTypes = make(map[string][]float64)
+	loader.improperTypes = make(map[string][]float64)
+
 	// read space dimention section
 	if err := readSpaceDimentionSection(loader); err != nil {
 		return err
@@ -174,6 +256,28 @@ func (loader *LammpsLoader) loadMetadata() error {
 	return nil
 }
 
+// readUnits takes the unit style from the "units = ..." part of the write_data header line.
+func readUnits(content string) string {
+	firstLine, _, _ := strings.Cut(content, "\n")
+	_, units, found := strings.Cut(firstLine, "units = ")
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(strings.Split(units, ",")[0])
+}
+
+// readBoundary takes the boundary styles from the "boundary = ..." part of the header line written by this project.
+func readBoundary(content string) [3]string {
+	firstLine, _, _ := strings.Cut(content, "\n")
+	_, boundary, found := strings.Cut(firstLine, "boundary = ")
+	if !found {
+		return [3]string{}
+	}
+	var result [3]string
+	copy(result[:], strings.Fields(strings.Split(boundary, ",")[0]))
+	return result
+}
+
 func getNumber(s string) (int, error) {
 	if len(s) == 0 {
 		return 0, errors.New("string is empty")
@@ -183,7 +287,7 @@ func getNumber(s string) (int, error) {
 
 	var builder strings.Builder
 	currentSymbol := 0
-	for isNumber(s[currentSymbol]) && currentSymbol < len(s) {
+	for currentSymbol < len(s) && isNumber(s[currentSymbol]) {
 		builder.WriteByte(s[currentSymbol])
 		currentSymbol++
 	}
@@ -208,6 +312,25 @@ func readMetadata(loader *LammpsLoader, metadata *int, sectionName string) error
 	return nil
 }
 
+// readOptionalMetadata reads a header line like "12 angles"; a missing line means zero.
+func readOptionalMetadata(loader *LammpsLoader, metadata *int, keyword string) error {
+	*metadata = 0
+	scanner := bufio.NewScanner(strings.NewReader(loader.content))
+	for scanner.Scan() {
+		fields := strings.Fields(sectionName(scanner.Text()))
+		if len(fields) < 2 || strings.Join(fields[1:], " ") != keyword {
+			continue
+		}
+		count, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return fmt.Errorf("wrong count of %s: %w", keyword, err)
+		}
+		*metadata = count
+		return nil
+	}
+	return nil
+}
+
 func readSpaceDimentionSection(loader *LammpsLoader) error {
 	scanner := bufio.NewScanner(strings.NewReader(loader.content))
 	for scanner.Scan() {
@@ -218,6 +341,10 @@ func readSpaceDimentionSection(loader *LammpsLoader) error {
 			readSpaceDimention(loader, line, DIMENTION_TYPE_Y)
 		} else if strings.Contains(line, "zlo") {
 			readSpaceDimention(loader, line, DIMENTION_TYPE_Z)
+		} else if strings.Contains(line, "xy xz yz") {
+			if err := readTiltFactors(loader, line); err != nil {
+				return err
+			}
 		} else {
 			continue
 		}
@@ -246,14 +373,29 @@ func readSpaceDimention(loader *LammpsLoader, line string, dimentionType Dimenti
 	return nil
 }
 
+func readTiltFactors(loader *LammpsLoader, line string) error {
+	// The template is the following:
+	// xy_value xz_value yz_value 'xy' 'xz' 'yz'
+	part := strings.Split(line, " ")
+	if len(part) != 6 {
+		return errors.New("wrong structure in the tilt factors line")
+	}
+	for i := 0; i < 3; i++ {
+		value, err := strconv.ParseFloat(part[i], 64)
+		if err != nil {
+			return err
+		}
+		loader.tiltFactors[i] = value
+	}
+	loader.triclinic = true
+	return nil
+}
+
 func (loader *LammpsLoader) loadMasses() error {
 	loader.scanner.Scan()
 
-	for atomTypeLineNumber := 0; atomTypeLineNumber < loader.atomTypesCount && loader.scanner.Scan(); atomTypeLineNumber++ {
+	for atomTypeLineNumber := 0; loader.scanSectionLine(); atomTypeLineNumber++ {
 		line := scannerText(loader.scanner.Text())
-		if len(line) == 0 {
-			break
-		}
 		parts := strings.Split(line, " ")
 		if len(parts) < 2 || len(parts) > 4 {
 			return fmt.Errorf("wrong line in the Masses section (line number in there: %d)", atomTypeLineNumber+1)
@@ -263,10 +405,11 @@ func (loader *LammpsLoader) loadMasses() error {
 		if err != nil {
 			return err
 		}
+		// a type without the comment has no label (e.g. data files made from restart files)
 		var label string
 		if len(parts) == 4 {
 			label = parts[3]
-		} else {
+		} else if len(parts) != 2 {
 			return errors.New("отсутсвуют названия элементов в Masses")
 		}
 		loader.atomTypes[number] = _MiddleAtom{
@@ -281,7 +424,7 @@ func (loader *LammpsLoader) loadMasses() error {
 func (loader *LammpsLoader) loadBondTypes() error {
 	loader.scanner.Scan()
 
-	for bondTypeLineNumber := 0; bondTypeLineNumber < loader.bondTypesCount && loader.scanner.Scan(); bondTypeLineNumber++ {
+	for bondTypeLineNumber := 0; loader.scanSectionLine(); bondTypeLineNumber++ {
 		parts := strings.Split(scannerText(loader.scanner.Text()), " ")
 		if len(parts) < 3 {
 			return fmt.Errorf("wrong line in the Bond Coeffs section (line number in there: %d)", bondTypeLineNumber+1)
@@ -307,8 +450,8 @@ func (loader *LammpsLoader) loadBondTypes() error {
 func (loader *LammpsLoader) loadAtoms() error {
 	loader.scanner.Scan()
 
-	for atomLineNumber := 0; atomLineNumber < loader.atomsCount && loader.scanner.Scan(); atomLineNumber++ {
-		parts := strings.Split(scannerText(loader.scanner.Text()), " ")
+	for atomLineNumber := 0; loader.scanSectionLine(); atomLineNumber++ {
+		parts := strings.Fields(sectionName(loader.scanner.Text()))
 		if len(parts) < 7 {
 			return fmt.Errorf("wrong line in the Atoms section (line number in there: %d)", atomLineNumber+1)
 		}
@@ -326,7 +469,10 @@ func (loader *LammpsLoader) loadAtoms() error {
 		atomTypeNumber := parts[2]
 		atomType, _ := strconv.Atoi(atomTypeNumber)
 
-		// Miss the charge because of not needing it
+		q, err := strconv.ParseFloat(parts[3], 64)
+		if err != nil {
+			return err
+		}
 
 		x, err := strconv.ParseFloat(clearNumber(parts[4]), 64)
 		if err != nil {
@@ -347,12 +493,21 @@ func (loader *LammpsLoader) loadAtoms() error {
 			int(atomID),
 			polymerID,
 			atomType,
-			0.0,
+			q,
 			x,
 			y,
 			z)
 
-		loader.atoms.setAtom(atom)
+		// the image flags are optional, so they are read only when all three of them are given
+		if len(parts) == 10 {
+			for i := range atom.Image {
+				if atom.Image[i], err = strconv.Atoi(parts[7+i]); err != nil {
+					return err
+				}
+			}
+		}
+
+		loader.atoms = append(loader.atoms, atom)
 	}
 	return nil
 }
@@ -361,7 +516,7 @@ func (loader *LammpsLoader) loadBonds() error {
 	loader.scanner.Scan()
 
 	loader.bonds = make([]*Bond, 0)
-	for bondLineNumber := 0; bondLineNumber < loader.bondsCount && loader.scanner.Scan(); bondLineNumber++ {
+	for bondLineNumber := 0; loader.scanSectionLine(); bondLineNumber++ {
 		parts := strings.Split(scannerText(loader.scanner.Text()), " ")
 		if len(parts) < 4 {
 			return fmt.Errorf("wrong line in the Bonds section (line number in there: %d)", bondLineNumber+1)
@@ -392,11 +547,112 @@ func (loader *LammpsLoader) loadBonds() error {
 	return nil
 }
 
+// loadCoeffs reads a "* Coeffs" section of the angles, dihedrals or impropers into the coefficients map.
+func (loader *LammpsLoader) loadCoeffs(section string, coeffs map[string][]float64) error {
+	loader.scanner.Scan()
+
+	for typeLineNumber := 0; loader.scanSectionLine(); typeLineNumber++ {
+		parts := strings.Fields(sectionName(loader.scanner.Text()))
+		if len(parts) < 1 {
+			return fmt.Errorf("wrong line in the %s section (line number in there: %d)", section, typeLineNumber+1)
+		}
+		values := make([]float64, 0, len(parts)-1)
+		for _, part := range parts[1:] {
+			value, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+		}
+		coeffs[parts[0]] = values
+	}
+	return nil
+}
+
+// readTopologyLine reads a line of the Bonds, Angles, Dihedrals or Impropers sections: ID, type and atom IDs.
+func readTopologyLine(line string, numbersCount int, section string, lineNumber int) ([]int, error) {
+	parts := strings.Fields(sectionName(line))
+	if len(parts) < numbersCount {
+		return nil, fmt.Errorf("wrong line in the %s section (line number in there: %d)", section, lineNumber+1)
+	}
+	numbers := make([]int, numbersCount)
+	for i := range numbers {
+		number, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+func (loader *LammpsLoader) loadAngles() error {
+	loader.scanner.Scan()
+
+	loader.angles = make([]*Angle, 0, loader.anglesCount)
+	for angleLineNumber := 0; loader.scanSectionLine(); angleLineNumber++ {
+		numbers, err := readTopologyLine(loader.scanner.Text(), 5, "Angles", angleLineNumber)
+		if err != nil {
+			return err
+		}
+		loader.angles = append(loader.angles, NewAngle(numbers[0], numbers[1], [3]int(numbers[2:])))
+	}
+	return nil
+}
+
+func (loader *LammpsLoader) loadDihedrals() error {
+	loader.scanner.Scan()
+
+	loader.dihedrals = make([]*Dihedral, 0, loader.dihedralsCount)
+	for dihedralLineNumber := 0; loader.scanSectionLine(); dihedralLineNumber++ {
+		numbers, err := readTopologyLine(loader.scanner.Text(), 6, "Dihedrals", dihedralLineNumber)
+		if err != nil {
+			return err
+		}
+		loader.dihedrals = append(loader.dihedrals, NewDihedral(numbers[0], numbers[1], [4]int(numbers[2:])))
+	}
+	return nil
+}
+
+func (loader *LammpsLoader) loadImpropers() error {
+	loader.scanner.Scan()
+
+	loader.impropers = make([]*Improper, 0, loader.impropersCount)
+	for improperLineNumber := 0; loader.scanSectionLine(); improperLineNumber++ {
+		numbers, err := readTopologyLine(loader.scanner.Text(), 6, "Impropers", improperLineNumber)
+		if err != nil {
+			return err
+		}
+		loader.impropers = append(loader.impropers, NewImproper(numbers[0], numbers[1], [4]int(numbers[2:])))
+	}
+	return nil
+}
+
+// sortedCoeffs converts the coefficients map into a list sorted by the type numbers.
+func sortedCoeffs(coeffs map[string][]float64) ([]int, [][]float64) {
+	types := make([]int, 0, len(coeffs))
+	for typeS := range coeffs {
+		if typeNumber, err := strconv.Atoi(typeS); err == nil {
+			types = append(types, typeNumber)
+		}
+	}
+	slices.Sort(types)
+	values := make([][]float64, len(types))
+	for i, typeNumber := range types {
+		values[i] = coeffs[strconv.Itoa(typeNumber)]
+	}
+	return types, values
+}
+
 func (loader *LammpsLoader) constructLammpsStruct() error {
 	loader.builtGlobula = NewLammpsStruct(len(loader.atoms), len(loader.bonds), len(loader.atomTypes), len(loader.bondTypes))
 	for i := range loader.atoms {
 		loader.builtGlobula.Atoms[i] = *loader.atoms[i]
 	}
+	// the atoms may be listed in any order; duplicated IDs are kept for the validation
+	slices.SortStableFunc(loader.builtGlobula.Atoms, func(a1, a2 Atom) int {
+		return a1.AtomID - a2.AtomID
+	})
 	j := 0
 	for atomTypeS := range loader.atomTypes {
 		if atomType, err := strconv.Atoi(atomTypeS); err == nil {
@@ -423,7 +679,7 @@ func (loader *LammpsLoader) constructLammpsStruct() error {
 	}
 	j = 0
 	for bondTypeS := range loader.bondTypes {
-		if bondType, err := strconv.Atoi(bondTypeS); err != nil {
+		if bondType, err := strconv.Atoi(bondTypeS); err == nil {
 			loader.builtGlobula.BondTypes[j] = BondType{
 				BondID: bondType,
 				Sth1:   loader.bondTypes[bondTypeS].sth1,
@@ -447,15 +703,50 @@ func (loader *LammpsLoader) constructLammpsStruct() error {
 			loader.builtGlobula.SpaceDimention[i][k] = loader.spaceDimention[i][k]
 		}
 	}
+	loader.builtGlobula.Triclinic = loader.triclinic
+	loader.builtGlobula.TiltFactors = loader.tiltFactors
+	loader.builtGlobula.Units = loader.units
+	loader.builtGlobula.Boundary = loader.boundary
+	loader.builtGlobula.HeaderCounts = map[string]int{
+		"atoms":          loader.atomsCount,
+		"atom types":     loader.atomTypesCount,
+		"bonds":          loader.bondsCount,
+		"bond types":     loader.bondTypesCount,
+		"angles":         loader.anglesCount,
+		"angle types":    loader.angleTypesCount,
+		"dihedrals":      loader.dihedralsCount,
+		"dihedral types": loader.dihedralTypesCount,
+		"impropers":      loader.impropersCount,
+		"improper types": loader.improperTypesCount,
+	}
+
+	for _, angle := range loader.angles {
+		loader.builtGlobula.Angles = append(loader.builtGlobula.Angles, *angle)
+	}
+	for _, dihedral := range loader.dihedrals {
+		loader.builtGlobula.Dihedrals = append(loader.builtGlobula.Dihedrals, *dihedral)
+	}
+	for _, improper := range loader.impropers {
+		loader.builtGlobula.Impropers = append(loader.builtGlobula.Impropers, *improper)
+	}
+	types, coeffs := sortedCoeffs(loader.angleTypes)
+	for i := range types {
+		loader.builtGlobula.AngleTypes = append(loader.builtGlobula.AngleTypes, AngleType{AngleID: types[i], Coeffs: coeffs[i]})
+	}
+	types, coeffs = sortedCoeffs(loader.dihedralTypes)
+	for i := range types {
+		loader.builtGlobula.DihedralTypes = append(loader.builtGlobula.DihedralTypes, DihedralType{DihedralID: types[i], Coeffs: coeffs[i]})
+	}
+	types, coeffs = sortedCoeffs(loader.improperTypes)
+	for i := range types {
+		loader.builtGlobula.ImproperTypes = append(loader.builtGlobula.ImproperTypes, ImproperType{ImproperID: types[i], Coeffs: coeffs[i]})
+	}
 
 	return nil
 }
 
+var numberPattern = regexp.MustCompile(`[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?`)
+
 func clearNumber(num string) string {
-	pattern := `[-]?\d{1,2}(\.\d*)*`
-	expr, err := regexp.Compile(pattern)
-	if err != nil {
-		return ""
-	}
-	return expr.FindString(num)
+	return numberPattern.FindString(num)
 }
@@ -0,0 +1,351 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+type TypesMerging = int
+
+const (
+	// TYPES_OFFSET gives every system its own types, numbered after the types of the previous systems.
+	TYPES_OFFSET TypesMerging = iota
+	// TYPES_BY_NUMBER keeps the type numbers: the systems share one force field,
+	// so the same number must have the same mass, label and coefficients in every system.
+	TYPES_BY_NUMBER
+	// TYPES_BY_LABEL unifies the atom types of a system with the atom types of the previous systems
+	// with the same label and mass, and the bond, angle, dihedral and improper types with the ones
+	// with the same coefficients; the others get new numbers. The types within one system are never
+	// unified, so a previous type takes at most one type of a system, and neither are the atom types
+	// without a label nor the other types without coefficients (all zero or none). An atom type with
+	// a label of the previous systems and none of their masses is a conflict.
+	TYPES_BY_LABEL
+)
+
+// MergeOptions set how Merge combines the types of the systems.
+type MergeOptions struct {
+	Types TypesMerging
+}
+
+// _TypeEntry is a row of a Masses or a Coeffs section of the merged system.
+type _TypeEntry struct {
+	label  string
+	coeffs []float64
+}
+
+// _TypesMerger numbers the types of one kind (atom, bond, angle...) of the merged system.
+type _TypesMerger struct {
+	mode    TypesMerging
+	section string
+	// labelled is set for the atom types, which are unified by their labels and need one
+	labelled bool
+	entries  map[int]_TypeEntry
+	count    int
+}
+
+func newTypesMerger(mode TypesMerging, section string, labelled bool) *_TypesMerger {
+	return &_TypesMerger{mode: mode, section: section, labelled: labelled, entries: make(map[int]_TypeEntry)}
+}
+
+/*
+add merges the types 1..typesCount of a system, of which the listed ones have
+the entries of the section, and returns the numbers of the types in the merged system.
+*/
+func (merger *_TypesMerger) add(typesCount int, ids []int, entries []_TypeEntry) (map[int]int, error) {
+	listed := make(map[int]_TypeEntry, len(ids))
+	for i, id := range ids {
+		listed[id] = entries[i]
+		typesCount = max(typesCount, id)
+	}
+	numbers := make(map[int]int, typesCount)
+	offset := merger.count
+	// the types of this system are matched against the previous systems only
+	previous := maps.Clone(merger.entries)
+	// taken holds the previous types already matched by a type of this system
+	taken := make(map[int]bool)
+	for id := 1; id <= typesCount; id++ {
+		entry, isListed := listed[id]
+		switch merger.mode {
+		case TYPES_OFFSET:
+			numbers[id] = offset + id
+		case TYPES_BY_NUMBER:
+			numbers[id] = id
+			if existing, ok := merger.entries[id]; ok && isListed && !existing.equals(entry) {
+				return nil, fmt.Errorf("%s: the type %d differs between the systems", merger.section, id)
+			}
+		case TYPES_BY_LABEL:
+			numbers[id] = 0
+			if isListed {
+				number, err := merger.find(previous, taken, entry)
+				if err != nil {
+					return nil, err
+				}
+				numbers[id] = number
+				taken[number] = true
+			}
+			if numbers[id] == 0 {
+				merger.count++
+				numbers[id] = merger.count
+			}
+		}
+		if isListed {
+			if _, ok := merger.entries[numbers[id]]; !ok {
+				merger.entries[numbers[id]] = entry
+			}
+		}
+		merger.count = max(merger.count, numbers[id])
+	}
+	return numbers, nil
+}
+
+// find returns the number of the equal type among the previous ones not taken yet, or zero if there is none.
+func (merger *_TypesMerger) find(previous map[int]_TypeEntry, taken map[int]bool, entry _TypeEntry) (int, error) {
+	if merger.labelled && len(entry.label) == 0 {
+		return 0, nil
+	}
+	if !merger.labelled && !slices.ContainsFunc(entry.coeffs, func(coeff float64) bool { return coeff != 0 }) {
+		return 0, nil
+	}
+	numbers := slices.Sorted(maps.Keys(previous))
+	for _, number := range numbers {
+		if !taken[number] && previous[number].equals(entry) {
+			return number, nil
+		}
+	}
+	if slices.ContainsFunc(numbers, func(number int) bool { return previous[number].equals(entry) }) {
+		// the same type is taken by another type of this system, so this one gets a new number
+		return 0, nil
+	}
+	for _, number := range numbers {
+		if existing := previous[number]; merger.labelled && existing.label == entry.label {
+			return 0, fmt.Errorf("%s: the type %s has different masses %g and %g", merger.section, entry.label, existing.coeffs[0], entry.coeffs[0])
+		}
+	}
+	return 0, nil
+}
+
+func (entry _TypeEntry) equals(other _TypeEntry) bool {
+	return entry.label == other.label && equalCoeffs(entry.coeffs, other.coeffs)
+}
+
+// sorted returns the numbers and the entries of the merged types.
+func (merger *_TypesMerger) sorted() ([]int, []_TypeEntry) {
+	numbers := slices.Sorted(maps.Keys(merger.entries))
+	entries := make([]_TypeEntry, len(numbers))
+	for i, number := range numbers {
+		entries[i] = merger.entries[number]
+	}
+	return numbers, entries
+}
+
+/*
+Merge combines several systems into one. The atoms, molecules, bonds, angles, dihedrals
+and impropers of every system are numbered after the ones of the previous systems, and
+the types are combined as the options say. The box encloses the boxes of all the systems;
+when the boxes differ, the atoms are moved by their image flags (the molecules become whole)
+and the image flags are reset. Triclinic boxes are merged only when they are the same.
+
+Params:
+  - systems: the systems to merge; they are not changed
+  - options: how to combine the types
+
+Returns:
+  - LammpsStruct: the merged system
+  - error: a conflict of the units, the types or the boxes
+*/
+func Merge(systems []*LammpsStruct, options MergeOptions) (*LammpsStruct, error) {
+	if len(systems) == 0 {
+		return nil, errors.New("nothing to merge")
+	}
+	result := &LammpsStruct{}
+	for _, system := range systems {
+		if len(system.Units) == 0 {
+			continue
+		}
+		if len(result.Units) != 0 && result.Units != system.Units {
+			return nil, fmt.Errorf("the systems have different units: %s and %s", result.Units, system.Units)
+		}
+		result.Units = system.Units
+	}
+	sameBoxes, err := mergeBoxes(result, systems)
+	if err != nil {
+		return nil, err
+	}
+
+	atomTypes := newTypesMerger(options.Types, "Masses", true)
+	bondTypes := newTypesMerger(options.Types, "Bond Coeffs", false)
+	angleTypes := newTypesMerger(options.Types, "Angle Coeffs", false)
+	dihedralTypes := newTypesMerger(options.Types, "Dihedral Coeffs", false)
+	improperTypes := newTypesMerger(options.Types, "Improper Coeffs", false)
+	atomsOffset, moleculesOffset := 0, 0
+	bondsOffset, anglesOffset, dihedralsOffset, impropersOffset := 0, 0, 0, 0
+	for _, system := range systems {
+		atomTypeNumbers, err := atomTypes.add(typesCount(system, "atom types", system.Atoms, func(atom Atom) int { return atom.AtomType }),
+			collectIDs(system.AtomTypes, func(atomType AtomType) int { return atomType.AtomType }),
+			collectEntries(system.AtomTypes, func(atomType AtomType) _TypeEntry {
+				return _TypeEntry{label: atomType.AtomLabel, coeffs: []float64{atomType.AtomMass}}
+			}))
+		if err != nil {
+			return nil, err
+		}
+		bondTypeNumbers, err := bondTypes.add(typesCount(system, "bond types", system.Bonds, func(bond Bond) int { return bond.ConnectionType }),
+			collectIDs(system.BondTypes, func(bondType BondType) int { return bondType.BondID }),
+			collectEntries(system.BondTypes, func(bondType BondType) _TypeEntry {
+				return _TypeEntry{coeffs: []float64{bondType.Sth1, bondType.Sth2}}
+			}))
+		if err != nil {
+			return nil, err
+		}
+		angleTypeNumbers, err := angleTypes.add(typesCount(system, "angle types", system.Angles, func(angle Angle) int { return angle.AngleType }),
+			collectIDs(system.AngleTypes, func(angleType AngleType) int { return angleType.AngleID }),
+			collectEntries(system.AngleTypes, func(angleType AngleType) _TypeEntry { return _TypeEntry{coeffs: angleType.Coeffs} }))
+		if err != nil {
+			return nil, err
+		}
+		dihedralTypeNumbers, err := dihedralTypes.add(typesCount(system, "dihedral types", system.Dihedrals, func(dihedral Dihedral) int { return dihedral.DihedralType }),
+			collectIDs(system.DihedralTypes, func(dihedralType DihedralType) int { return dihedralType.DihedralID }),
+			collectEntries(system.DihedralTypes, func(dihedralType DihedralType) _TypeEntry { return _TypeEntry{coeffs: dihedralType.Coeffs} }))
+		if err != nil {
+			return nil, err
+		}
+		improperTypeNumbers, err := improperTypes.add(typesCount(system, "improper types", system.Impropers, func(improper Improper) int { return improper.ImproperType }),
+			collectIDs(system.ImproperTypes, func(improperType ImproperType) int { return improperType.ImproperID }),
+			collectEntries(system.ImproperTypes, func(improperType ImproperType) _TypeEntry { return _TypeEntry{coeffs: improperType.Coeffs} }))
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range system.Atoms {
+			atom := system.Atoms[i]
+			if !sameBoxes {
+				atom.AtomCoords = system.unwrapped(&system.Atoms[i])
+				atom.Image = [3]int{}
+			}
+			atom.AtomID += atomsOffset
+			if atom.MoleculeID != 0 {
+				atom.MoleculeID += moleculesOffset
+			}
+			atom.AtomType = atomTypeNumbers[atom.AtomType]
+			result.Atoms = append(result.Atoms, atom)
+		}
+		for _, bond := range system.Bonds {
+			bond.BondID += bondsOffset
+			bond.ConnectionType = bondTypeNumbers[bond.ConnectionType]
+			bond.Ends = [2]int{bond.Ends[0] + atomsOffset, bond.Ends[1] + atomsOffset}
+			result.Bonds = append(result.Bonds, bond)
+		}
+		for _, angle := range system.Angles {
+			angle.AngleID += anglesOffset
+			angle.AngleType = angleTypeNumbers[angle.AngleType]
+			for i := range angle.Atoms {
+				angle.Atoms[i] += atomsOffset
+			}
+			result.Angles = append(result.Angles, angle)
+		}
+		for _, dihedral := range system.Dihedrals {
+			dihedral.DihedralID += dihedralsOffset
+			dihedral.DihedralType = dihedralTypeNumbers[dihedral.DihedralType]
+			for i := range dihedral.Atoms {
+				dihedral.Atoms[i] += atomsOffset
+			}
+			result.Dihedrals = append(result.Dihedrals, dihedral)
+		}
+		for _, improper := range system.Impropers {
+			improper.ImproperID += impropersOffset
+			improper.ImproperType = improperTypeNumbers[improper.ImproperType]
+			for i := range improper.Atoms {
+				improper.Atoms[i] += atomsOffset
+			}
+			result.Impropers = append(result.Impropers, improper)
+		}
+
+		atomsOffset += maxID(system.Atoms, func(atom Atom) int { return atom.AtomID })
+		moleculesOffset += maxID(system.Atoms, func(atom Atom) int { return atom.MoleculeID })
+		bondsOffset += maxID(system.Bonds, func(bond Bond) int { return bond.BondID })
+		anglesOffset += maxID(system.Angles, func(angle Angle) int { return angle.AngleID })
+		dihedralsOffset += maxID(system.Dihedrals, func(dihedral Dihedral) int { return dihedral.DihedralID })
+		impropersOffset += maxID(system.Impropers, func(improper Improper) int { return improper.ImproperID })
+	}
+
+	numbers, entries := atomTypes.sorted()
+	for i, number := range numbers {
+		result.AtomTypes = append(result.AtomTypes, AtomType{AtomType: number, AtomMass: entries[i].coeffs[0], AtomLabel: entries[i].label})
+	}
+	numbers, entries = bondTypes.sorted()
+	for i, number := range numbers {
+		result.BondTypes = append(result.BondTypes, BondType{BondID: number, Sth1: entries[i].coeffs[0], Sth2: entries[i].coeffs[1]})
+	}
+	numbers, entries = angleTypes.sorted()
+	for i, number := range numbers {
+		result.AngleTypes = append(result.AngleTypes, AngleType{AngleID: number, Coeffs: entries[i].coeffs})
+	}
+	numbers, entries = dihedralTypes.sorted()
+	for i, number := range numbers {
+		result.DihedralTypes = append(result.DihedralTypes, DihedralType{DihedralID: number, Coeffs: entries[i].coeffs})
+	}
+	numbers, entries = improperTypes.sorted()
+	for i, number := range numbers {
+		result.ImproperTypes = append(result.ImproperTypes, ImproperType{ImproperID: number, Coeffs: entries[i].coeffs})
+	}
+	return result, nil
+}
+
+// mergeBoxes sets the box enclosing the boxes of the systems and tells if all the boxes are the same.
+func mergeBoxes(result *LammpsStruct, systems []*LammpsStruct) (bool, error) {
+	first := systems[0]
+	result.SpaceDimention, result.Triclinic, result.TiltFactors = first.SpaceDimention, first.Triclinic, first.TiltFactors
+	result.Boundary = first.Boundary
+	sameBoxes := true
+	for _, system := range systems[1:] {
+		if system.SpaceDimention != first.SpaceDimention || system.Triclinic != first.Triclinic ||
+			(system.Triclinic && system.TiltFactors != first.TiltFactors) {
+			sameBoxes = false
+		}
+	}
+	if sameBoxes {
+		return true, nil
+	}
+	for _, system := range systems {
+		if system.Triclinic {
+			return false, errors.New("the triclinic boxes may be merged only when all the boxes are the same")
+		}
+		for axis, bounds := range system.SpaceDimention {
+			result.SpaceDimention[axis][0] = min(result.SpaceDimention[axis][0], bounds[0])
+			result.SpaceDimention[axis][1] = max(result.SpaceDimention[axis][1], bounds[1])
+		}
+	}
+	// the unwrapped atoms may stick out of the boxes
+	for _, system := range systems {
+		for i := range system.Atoms {
+			coords := system.unwrapped(&system.Atoms[i])
+			for axis, coord := range [3]float64{coords.X, coords.Y, coords.Z} {
+				result.SpaceDimention[axis][0] = min(result.SpaceDimention[axis][0], coord)
+				result.SpaceDimention[axis][1] = max(result.SpaceDimention[axis][1], coord)
+			}
+		}
+	}
+	return false, nil
+}
+
+// typesCount returns the number of the types of a kind: the declared one or the largest used type.
+func typesCount[T any](lammpsStruct *LammpsStruct, headerName string, items []T, itemType func(T) int) int {
+	return max(lammpsStruct.HeaderCounts[headerName], maxID(items, itemType))
+}
+
+func maxID[T any](items []T, id func(T) int) int {
+	result := 0
+	for _, item := range items {
+		result = max(result, id(item))
+	}
+	return result
+}
+
+func collectEntries[T any](items []T, entry func(T) _TypeEntry) []_TypeEntry {
+	entries := make([]_TypeEntry, len(items))
+	for i, item := range items {
+		entries[i] = entry(item)
+	}
+	return entries
+}
@@ -0,0 +1,166 @@
+package structs
+
+import (
+	"fmt"
+	"slices"
+	"unicode"
+)
+
+// covalentRadii are the single bond covalent radii in Å by the elements (B. Cordero et al., Dalton Trans., 2008).
+var covalentRadii = map[string]float64{
+	"H": 0.31, "He": 0.28,
+	"Li": 1.28, "Be": 0.96, "B": 0.84, "C": 0.76, "N": 0.71, "O": 0.66, "F": 0.57, "Ne": 0.58,
+	"Na": 1.66, "Mg": 1.41, "Al": 1.21, "Si": 1.11, "P": 1.07, "S": 1.05, "Cl": 1.02, "Ar": 1.06,
+	"K": 2.03, "Ca": 1.76, "Ti": 1.60, "Cr": 1.39, "Mn": 1.39, "Fe": 1.32, "Co": 1.26, "Ni": 1.24,
+	"Cu": 1.32, "Zn": 1.22, "Ga": 1.22, "Ge": 1.20, "As": 1.19, "Se": 1.20, "Br": 1.20, "Kr": 1.16,
+	"Ag": 1.45, "Sn": 1.39, "I": 1.39, "Xe": 1.40, "Pt": 1.36, "Au": 1.36, "Pb": 1.46,
+}
+
+// BondTypeRule gives a type to the perceived bonds between two elements in any order; an element may be ANY_ATOM.
+type BondTypeRule struct {
+	Elements [2]string
+	Type     int
+}
+
+// PerceiveOptions set when two atoms are bonded and how the bonds are typed.
+type PerceiveOptions struct {
+	// Tolerance multiplies the sum of the covalent radii of two atoms to get the longest bond between them
+	Tolerance float64
+	// MinDistance is the shortest bond; closer atoms are overlapping rather than bonded
+	MinDistance float64
+	// Radii add or replace the covalent radii by the elements
+	Radii map[string]float64
+	// BondTypes are tried in their order; the bonds matching no rule get a new type for each pair of
+	// elements, numbered after the bond types already used
+	BondTypes []BondTypeRule
+}
+
+type _PerceivedBond struct {
+	ends     [2]int
+	elements [2]string
+}
+
+// DefaultPerceiveOptions returns the options working for most organic molecules.
+func DefaultPerceiveOptions() PerceiveOptions {
+	return PerceiveOptions{
+		Tolerance:   1.15,
+		MinDistance: 0.4,
+	}
+}
+
+/*
+PerceiveBonds replaces the bonds by the ones found from the distances between the atoms: two atoms
+are bonded when they are not farther than the sum of their covalent radii times the tolerance.
+The elements are taken from the labels of the atoms or of their types, e.g. "C", "Cl" or "CA"
+for a carbon. The distances are in Å and follow the minimum image convention of the box.
+The new bond types are declared with zero coefficients, and the header counts of a loaded file are updated.
+
+Params:
+  - options: the tolerance, the radii and the rules for the bond types
+
+Returns:
+  - error: an atom without a known element; the structure is not changed then
+*/
+func (lammpsStruct *LammpsStruct) PerceiveBonds(options PerceiveOptions) error {
+	radii := make(map[string]float64, len(covalentRadii)+len(options.Radii))
+	for element, radius := range covalentRadii {
+		radii[element] = radius
+	}
+	for element, radius := range options.Radii {
+		radii[element] = radius
+	}
+	typeLabels := make(map[int]string, len(lammpsStruct.AtomTypes))
+	for _, atomType := range lammpsStruct.AtomTypes {
+		typeLabels[atomType.AtomType] = atomType.AtomLabel
+	}
+	elements := make([]string, len(lammpsStruct.Atoms))
+	maxRadius := 0.0
+	for i, atom := range lammpsStruct.Atoms {
+		label := atom.Label
+		if len(label) == 0 {
+			label = typeLabels[atom.AtomType]
+		}
+		element, ok := elementOf(label, radii)
+		if !ok {
+			return fmt.Errorf("no covalent radius for the atom %d with the label %q", atom.AtomID, label)
+		}
+		elements[i] = element
+		maxRadius = max(maxRadius, radii[element])
+	}
+
+	perceived := make([]_PerceivedBond, 0)
+	lammpsStruct.Pairs(2*maxRadius*options.Tolerance, func(first, second int, distance float64) {
+		if distance < options.MinDistance || distance > (radii[elements[first]]+radii[elements[second]])*options.Tolerance {
+			return
+		}
+		ends := [2]int{lammpsStruct.Atoms[first].AtomID, lammpsStruct.Atoms[second].AtomID}
+		if ends[1] < ends[0] {
+			ends[0], ends[1] = ends[1], ends[0]
+		}
+		perceived = append(perceived, _PerceivedBond{ends: ends, elements: [2]string{elements[first], elements[second]}})
+	})
+	slices.SortFunc(perceived, func(p1, p2 _PerceivedBond) int {
+		if p1.ends[0] != p2.ends[0] {
+			return p1.ends[0] - p2.ends[0]
+		}
+		return p1.ends[1] - p2.ends[1]
+	})
+
+	nextType := max(lammpsStruct.HeaderCounts["bond types"],
+		maxID(lammpsStruct.BondTypes, func(bondType BondType) int { return bondType.BondID }),
+		maxID(options.BondTypes, func(rule BondTypeRule) int { return rule.Type })) + 1
+	pairTypes := make(map[[2]string]int)
+	bonds := make([]Bond, len(perceived))
+	for i, pair := range perceived {
+		first, second := pair.elements[0], pair.elements[1]
+		bondType := bondTypeOf(options.BondTypes, first, second)
+		if bondType == 0 {
+			elementsPair := [2]string{min(first, second), max(first, second)}
+			if _, ok := pairTypes[elementsPair]; !ok {
+				pairTypes[elementsPair] = nextType
+				nextType++
+			}
+			bondType = pairTypes[elementsPair]
+		}
+		bonds[i] = Bond{BondID: i + 1, ConnectionType: bondType, Ends: pair.ends}
+	}
+
+	lammpsStruct.Bonds = bonds
+	for _, bond := range bonds {
+		lammpsStruct.BondTypes = declareType(lammpsStruct.BondTypes, bond.ConnectionType,
+			func(bondType *BondType) *int { return &bondType.BondID })
+	}
+	if lammpsStruct.HeaderCounts != nil {
+		lammpsStruct.HeaderCounts["bonds"] = len(bonds)
+		lammpsStruct.HeaderCounts["bond types"] = max(lammpsStruct.HeaderCounts["bond types"], len(lammpsStruct.BondTypes))
+	}
+	return nil
+}
+
+// elementOf finds the element of a label: a two letter element like "Cl" first, then the first letter.
+func elementOf(label string, radii map[string]float64) (string, bool) {
+	runes := []rune(label)
+	if len(runes) >= 2 && unicode.IsLower(runes[1]) {
+		if element := string(runes[:2]); radii[element] > 0 {
+			return element, true
+		}
+	}
+	if len(runes) >= 1 {
+		if element := string(unicode.ToUpper(runes[0])); radii[element] > 0 {
+			return element, true
+		}
+	}
+	return "", false
+}
+
+// bondTypeOf returns the type of the first rule matching the elements in any order, or 0.
+func bondTypeOf(rules []BondTypeRule, first, second string) int {
+	matches := func(pattern, element string) bool { return pattern == ANY_ATOM || pattern == element }
+	for _, rule := range rules {
+		if (matches(rule.Elements[0], first) && matches(rule.Elements[1], second)) ||
+			(matches(rule.Elements[0], second) && matches(rule.Elements[1], first)) {
+			return rule.Type
+		}
+	}
+	return 0
+}
@@ -0,0 +1,178 @@
+package structs
+
+import "math"
+
+// boxLengths returns the lengths of the box edges along the axes.
+func boxLengths(spaceDimention [3][2]float64) [3]float64 {
+	return [3]float64{
+		spaceDimention[0][1] - spaceDimention[0][0],
+		spaceDimention[1][1] - spaceDimention[1][0],
+		spaceDimention[2][1] - spaceDimention[2][0],
+	}
+}
+
+// boxVolume returns the volume of the box; the tilt factors do not change it.
+func (lammpsStruct *LammpsStruct) boxVolume() float64 {
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	return lengths[0] * lengths[1] * lengths[2]
+}
+
+// BoxWidths returns the distances between the opposite faces of the box; they are less than the lengths for a triclinic box.
+func (lammpsStruct *LammpsStruct) BoxWidths() [3]float64 {
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	if !lammpsStruct.Triclinic {
+		return lengths
+	}
+	xy, xz, yz := lammpsStruct.TiltFactors[0], lammpsStruct.TiltFactors[1], lammpsStruct.TiltFactors[2]
+	volume := lammpsStruct.boxVolume()
+	return [3]float64{
+		volume / math.Sqrt(lengths[1]*lengths[1]*lengths[2]*lengths[2]+xy*xy*lengths[2]*lengths[2]+(xy*yz-lengths[1]*xz)*(xy*yz-lengths[1]*xz)),
+		volume / (lengths[0] * math.Sqrt(lengths[2]*lengths[2]+yz*yz)),
+		lengths[2],
+	}
+}
+
+/*
+MinimumImage returns the shortest periodic image of the vector between two atoms.
+Like LAMMPS, a triclinic box is handled from the z axis down, so that removing
+a period along c or b also moves the vector along the tilted axes.
+*/
+func (lammpsStruct *LammpsStruct) MinimumImage(delta AtomCoords) AtomCoords {
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	xy, xz, yz := 0.0, 0.0, 0.0
+	if lammpsStruct.Triclinic {
+		xy, xz, yz = lammpsStruct.TiltFactors[0], lammpsStruct.TiltFactors[1], lammpsStruct.TiltFactors[2]
+	}
+	if lengths[2] > 0 {
+		shift := math.Round(delta.Z / lengths[2])
+		delta.Z -= shift * lengths[2]
+		delta.Y -= shift * yz
+		delta.X -= shift * xz
+	}
+	if lengths[1] > 0 {
+		shift := math.Round(delta.Y / lengths[1])
+		delta.Y -= shift * lengths[1]
+		delta.X -= shift * xy
+	}
+	if lengths[0] > 0 {
+		delta.X -= math.Round(delta.X/lengths[0]) * lengths[0]
+	}
+	return delta
+}
+
+// Displacement returns the vector from one point to another under the minimum image convention of the box.
+func (lammpsStruct *LammpsStruct) Displacement(from, to *AtomCoords) AtomCoords {
+	return lammpsStruct.MinimumImage(AtomCoords{
+		X: to.X - from.X,
+		Y: to.Y - from.Y,
+		Z: to.Z - from.Z,
+	})
+}
+
+// Distance returns the distance between two points under the minimum image convention of the box.
+func (lammpsStruct *LammpsStruct) Distance(first, second *AtomCoords) float64 {
+	delta := lammpsStruct.Displacement(first, second)
+	return math.Sqrt(delta.X*delta.X + delta.Y*delta.Y + delta.Z*delta.Z)
+}
+
+/*
+Wrap moves the atoms outside the box into it by whole box vectors and changes their image
+flags accordingly, so the unwrapped coordinates stay the same.
+*/
+func (lammpsStruct *LammpsStruct) Wrap() {
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	for i := range lammpsStruct.Atoms {
+		atom := &lammpsStruct.Atoms[i]
+		fractional := lammpsStruct.fractional(atom.AtomCoords)
+		var periods [3]int
+		for axis := range periods {
+			if lengths[axis] > 0 {
+				periods[axis] = int(math.Floor(fractional[axis]))
+			}
+		}
+		if periods == [3]int{} {
+			continue
+		}
+		atom.AtomCoords = lammpsStruct.shift(atom.AtomCoords, [3]int{-periods[0], -periods[1], -periods[2]})
+		for axis := range periods {
+			atom.Image[axis] += periods[axis]
+		}
+	}
+}
+
+// Unwrap moves the atoms by their image flags to where they would be without the periodic boundaries and clears the flags.
+func (lammpsStruct *LammpsStruct) Unwrap() {
+	for i := range lammpsStruct.Atoms {
+		atom := &lammpsStruct.Atoms[i]
+		atom.AtomCoords = lammpsStruct.unwrapped(atom)
+		atom.Image = [3]int{}
+	}
+}
+
+/*
+MakeWhole unwraps the molecules by the bonds rather than by the image flags, which are often
+wrong in generated files: starting from the atom with the smallest ID of every group of bonded
+atoms, each bonded atom is moved by whole box vectors next to its neighbour, so no bond spans
+the box. The image flags are changed by the same vectors, so the unwrapped coordinates stay the
+same when the flags were right. The atoms may end up outside the box.
+*/
+func (lammpsStruct *LammpsStruct) MakeWhole() {
+	graph := lammpsStruct.Graph()
+	placed := make([]bool, len(graph.ids))
+	for start := range graph.ids {
+		if placed[start] {
+			continue
+		}
+		placed[start] = true
+		queue := []int{start}
+		for i := 0; i < len(queue); i++ {
+			current := &lammpsStruct.Atoms[queue[i]]
+			for _, neighbour := range graph.neighbours[queue[i]] {
+				if placed[neighbour] {
+					continue
+				}
+				placed[neighbour] = true
+				queue = append(queue, neighbour)
+				atom := &lammpsStruct.Atoms[neighbour]
+				periods := lammpsStruct.imageShift(current, atom)
+				atom.AtomCoords = lammpsStruct.shift(atom.AtomCoords, periods)
+				for axis := range periods {
+					atom.Image[axis] -= periods[axis]
+				}
+			}
+		}
+	}
+}
+
+// fractional returns the coordinates of a point in the units of the box edges, 0..1 inside the box.
+func (lammpsStruct *LammpsStruct) fractional(coords AtomCoords) [3]float64 {
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	xy, xz, yz := 0.0, 0.0, 0.0
+	if lammpsStruct.Triclinic {
+		xy, xz, yz = lammpsStruct.TiltFactors[0], lammpsStruct.TiltFactors[1], lammpsStruct.TiltFactors[2]
+	}
+	var result [3]float64
+	result[2] = (coords.Z - lammpsStruct.SpaceDimention[2][0]) / lengths[2]
+	result[1] = (coords.Y - lammpsStruct.SpaceDimention[1][0] - result[2]*yz) / lengths[1]
+	result[0] = (coords.X - lammpsStruct.SpaceDimention[0][0] - result[1]*xy - result[2]*xz) / lengths[0]
+	return result
+}
+
+// fromFractional is the inverse of fractional.
+func (lammpsStruct *LammpsStruct) fromFractional(fractional [3]float64) AtomCoords {
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	xy, xz, yz := 0.0, 0.0, 0.0
+	if lammpsStruct.Triclinic {
+		xy, xz, yz = lammpsStruct.TiltFactors[0], lammpsStruct.TiltFactors[1], lammpsStruct.TiltFactors[2]
+	}
+	return AtomCoords{
+		X: lammpsStruct.SpaceDimention[0][0] + fractional[0]*lengths[0] + fractional[1]*xy + fractional[2]*xz,
+		Y: lammpsStruct.SpaceDimention[1][0] + fractional[1]*lengths[1] + fractional[2]*yz,
+		Z: lammpsStruct.SpaceDimention[2][0] + fractional[2]*lengths[2],
+	}
+}
+
+// unwrapped returns the coordinates of the atom moved by its image flags out of the box.
+func (lammpsStruct *LammpsStruct) unwrapped(atom *Atom) AtomCoords {
+	return lammpsStruct.shift(atom.AtomCoords, atom.Image)
+}
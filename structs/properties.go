@@ -0,0 +1,196 @@
+package structs
+
+import (
+	"math"
+	"slices"
+)
+
+// Properties are the geometric properties of a molecule or of the whole system; the masses come from AtomTypes.
+type Properties struct {
+	AtomsCount   int
+	Mass         float64
+	CenterOfMass AtomCoords
+	// GyrationTensor is the mass weighted gyration tensor around the center of mass
+	GyrationTensor [3][3]float64
+	// GyrationMoments are the eigenvalues of the gyration tensor in the ascending order
+	GyrationMoments  [3]float64
+	RadiusOfGyration float64
+	// Asphericity is λ3 - (λ1 + λ2) / 2 by the gyration moments, zero for a spherical shape
+	Asphericity float64
+	// Acylindricity is λ2 - λ1, zero for a cylindrical shape
+	Acylindricity float64
+	// ShapeAnisotropy is the relative shape anisotropy κ², from 0 for a sphere to 1 for a rod
+	ShapeAnisotropy float64
+	// Linear is set for an unbranched chain of bonds; EndToEndDistance is between its ends then
+	Linear           bool
+	EndToEndDistance float64
+	InertiaTensor    [3][3]float64
+	// PrincipalMoments are the eigenvalues of the inertia tensor in the ascending order
+	// and PrincipalAxes are the unit vectors along the corresponding axes
+	PrincipalMoments [3]float64
+	PrincipalAxes    [3]AtomCoords
+}
+
+/*
+MoleculeProperties computes the geometric properties of every molecule. The molecules are made
+whole by their bonds, so they may cross the periodic boundaries whatever their image flags; the
+parts of a molecule not bonded to each other are placed by their image flags.
+
+Returns:
+  - map[int]*Properties: the properties by the molecule IDs; the atoms with the molecule ID 0,
+    which belong to no molecule, are left out
+*/
+func (lammpsStruct *LammpsStruct) MoleculeProperties() map[int]*Properties {
+	graph := lammpsStruct.Graph()
+	positions := lammpsStruct.wholePositions(graph)
+	masses := lammpsStruct.atomMasses()
+	molecules := make(map[int][]int)
+	for i, atom := range lammpsStruct.Atoms {
+		if atom.MoleculeID != 0 {
+			molecules[atom.MoleculeID] = append(molecules[atom.MoleculeID], i)
+		}
+	}
+	result := make(map[int]*Properties, len(molecules))
+	for moleculeID, indices := range molecules {
+		result[moleculeID] = groupProperties(graph, indices, positions, masses)
+	}
+	return result
+}
+
+// SystemProperties computes the geometric properties of all the atoms with the molecules made whole like in MoleculeProperties.
+func (lammpsStruct *LammpsStruct) SystemProperties() *Properties {
+	graph := lammpsStruct.Graph()
+	indices := make([]int, len(lammpsStruct.Atoms))
+	for i := range indices {
+		indices[i] = i
+	}
+	return groupProperties(graph, indices, lammpsStruct.wholePositions(graph), lammpsStruct.atomMasses())
+}
+
+/*
+wholePositions returns the positions of the atoms with the bonded atoms next to each other: the
+first atom of every group of bonded atoms is unwrapped by its image flags and the others are put
+at the minimum image of the atom they are bonded to.
+*/
+func (lammpsStruct *LammpsStruct) wholePositions(graph *Graph) []AtomCoords {
+	positions := make([]AtomCoords, len(lammpsStruct.Atoms))
+	placed := make([]bool, len(lammpsStruct.Atoms))
+	for start := range lammpsStruct.Atoms {
+		if placed[start] {
+			continue
+		}
+		placed[start] = true
+		positions[start] = lammpsStruct.unwrapped(&lammpsStruct.Atoms[start])
+		queue := []int{start}
+		for i := 0; i < len(queue); i++ {
+			current := queue[i]
+			for _, neighbour := range graph.neighbours[current] {
+				if placed[neighbour] {
+					continue
+				}
+				placed[neighbour] = true
+				queue = append(queue, neighbour)
+				positions[neighbour] = addCoords(positions[current],
+					lammpsStruct.Displacement(&positions[current], &lammpsStruct.Atoms[neighbour].AtomCoords))
+			}
+		}
+	}
+	return positions
+}
+
+// groupProperties computes the properties of the atoms with the indices.
+func groupProperties(graph *Graph, indices []int, allPositions []AtomCoords, allMasses []float64) *Properties {
+	positions := make([]AtomCoords, len(indices))
+	masses := make([]float64, len(indices))
+	properties := &Properties{AtomsCount: len(indices)}
+	for i, index := range indices {
+		positions[i], masses[i] = allPositions[index], allMasses[index]
+		properties.Mass += masses[i]
+	}
+	if len(indices) == 0 {
+		return properties
+	}
+	properties.CenterOfMass = massCenter(positions, masses)
+
+	for i, position := range positions {
+		relative := subCoords(position, properties.CenterOfMass)
+		r := [3]float64{relative.X, relative.Y, relative.Z}
+		for j := range r {
+			for k := range r {
+				properties.GyrationTensor[j][k] += masses[i] * r[j] * r[k] / properties.Mass
+			}
+		}
+	}
+	moments, _ := symmetricEigen(properties.GyrationTensor)
+	properties.GyrationMoments = moments
+	squaredRadius := moments[0] + moments[1] + moments[2]
+	properties.RadiusOfGyration = math.Sqrt(max(squaredRadius, 0))
+	properties.Asphericity = moments[2] - (moments[0]+moments[1])/2
+	properties.Acylindricity = moments[1] - moments[0]
+	if squaredRadius > 0 {
+		properties.ShapeAnisotropy = (properties.Asphericity*properties.Asphericity +
+			0.75*properties.Acylindricity*properties.Acylindricity) / (squaredRadius * squaredRadius)
+	}
+
+	properties.InertiaTensor = inertiaTensor(positions, masses, properties.CenterOfMass)
+	properties.PrincipalMoments, properties.PrincipalAxes = symmetricEigen(properties.InertiaTensor)
+
+	if ends := chainEnds(graph, indices); ends != nil {
+		properties.Linear = true
+		delta := subCoords(allPositions[ends[1]], allPositions[ends[0]])
+		properties.EndToEndDistance = math.Sqrt(delta.X*delta.X + delta.Y*delta.Y + delta.Z*delta.Z)
+	}
+	return properties
+}
+
+/*
+chainEnds checks if the atoms with the indices are bonded into one unbranched chain.
+
+Returns:
+  - []int: the indices of the two ends of the chain, the one of the smaller ID first, or nil if
+    the atoms are not a chain
+*/
+func chainEnds(graph *Graph, indices []int) []int {
+	if len(indices) < 2 {
+		return nil
+	}
+	inside := make(map[int]bool, len(indices))
+	for _, index := range indices {
+		inside[index] = true
+	}
+	ends := make([]int, 0, 2)
+	for _, index := range indices {
+		degree := 0
+		for _, neighbour := range graph.neighbours[index] {
+			if inside[neighbour] {
+				degree++
+			}
+		}
+		switch degree {
+		case 1:
+			ends = append(ends, index)
+		case 2:
+		default:
+			return nil
+		}
+	}
+	if len(ends) != 2 {
+		return nil
+	}
+	// the degrees also fit a chain with separate rings, so the chain must pass all the atoms
+	previous, current, walked := -1, ends[0], 1
+	for current != ends[1] {
+		for _, neighbour := range graph.neighbours[current] {
+			if inside[neighbour] && neighbour != previous {
+				previous, current = current, neighbour
+				break
+			}
+		}
+		walked++
+	}
+	if walked != len(indices) {
+		return nil
+	}
+	slices.SortFunc(ends, func(e1, e2 int) int { return graph.ids[e1] - graph.ids[e2] })
+	return ends
+}
@@ -0,0 +1,200 @@
+package structs
+
+import (
+	"errors"
+	"math"
+)
+
+// _Replication holds the state of Replicate.
+type _Replication struct {
+	source *LammpsStruct
+	counts [3]int
+	atoms  map[int]*Atom
+	// maxAtomID is the offset of the atom IDs between the copies
+	maxAtomID int
+}
+
+/*
+Replicate builds a larger periodic system of nx * ny * nz copies of the structure along
+the box vectors, like the LAMMPS replicate command; triclinic boxes are supported.
+
+Every atom gets nx * ny * nz copies with the IDs (and the molecule IDs) offset by the
+largest ID times the copy number. The copies of a molecule are placed by the image flags,
+so the molecules crossing the box boundaries stay whole and get the new image flags.
+The bonds, angles, dihedrals and impropers connect the atoms along the minimum image,
+so the bonds across the boundaries of an infinite network (the bond/periodic case of LAMMPS)
+are reconnected to the neighbouring copies.
+
+Params:
+  - nx, ny, nz: the number of the copies along the box vectors
+
+Returns:
+  - LammpsStruct: the replicated system
+  - error: a wrong number of copies or a wrong box
+*/
+func (lammpsStruct *LammpsStruct) Replicate(nx, ny, nz int) (*LammpsStruct, error) {
+	if nx < 1 || ny < 1 || nz < 1 {
+		return nil, errors.New("the numbers of the copies must be positive")
+	}
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	if lengths[0] <= 0 || lengths[1] <= 0 || lengths[2] <= 0 {
+		return nil, errors.New("the box is empty")
+	}
+	replication := &_Replication{
+		source:    lammpsStruct,
+		counts:    [3]int{nx, ny, nz},
+		atoms:     make(map[int]*Atom, len(lammpsStruct.Atoms)),
+		maxAtomID: maxID(lammpsStruct.Atoms, func(atom Atom) int { return atom.AtomID }),
+	}
+	for i := range lammpsStruct.Atoms {
+		replication.atoms[lammpsStruct.Atoms[i].AtomID] = &lammpsStruct.Atoms[i]
+	}
+	copiesCount := nx * ny * nz
+
+	result := &LammpsStruct{
+		Units:         lammpsStruct.Units,
+		AtomTypes:     append([]AtomType(nil), lammpsStruct.AtomTypes...),
+		BondTypes:     append([]BondType(nil), lammpsStruct.BondTypes...),
+		AngleTypes:    append([]AngleType(nil), lammpsStruct.AngleTypes...),
+		DihedralTypes: append([]DihedralType(nil), lammpsStruct.DihedralTypes...),
+		ImproperTypes: append([]ImproperType(nil), lammpsStruct.ImproperTypes...),
+		Triclinic:     lammpsStruct.Triclinic,
+		Boundary:      lammpsStruct.Boundary,
+		Atoms:         make([]Atom, 0, len(lammpsStruct.Atoms)*copiesCount),
+		Bonds:         make([]Bond, 0, len(lammpsStruct.Bonds)*copiesCount),
+	}
+	for axis := range result.SpaceDimention {
+		lower := lammpsStruct.SpaceDimention[axis][0]
+		result.SpaceDimention[axis] = [2]float64{lower, lower + lengths[axis]*float64(replication.counts[axis])}
+	}
+	if lammpsStruct.Triclinic {
+		tilt := lammpsStruct.TiltFactors
+		result.TiltFactors = [3]float64{tilt[0] * float64(ny), tilt[1] * float64(nz), tilt[2] * float64(nz)}
+	}
+
+	maxMoleculeID := maxID(lammpsStruct.Atoms, func(atom Atom) int { return atom.MoleculeID })
+	maxBondID := maxID(lammpsStruct.Bonds, func(bond Bond) int { return bond.BondID })
+	maxAngleID := maxID(lammpsStruct.Angles, func(angle Angle) int { return angle.AngleID })
+	maxDihedralID := maxID(lammpsStruct.Dihedrals, func(dihedral Dihedral) int { return dihedral.DihedralID })
+	maxImproperID := maxID(lammpsStruct.Impropers, func(improper Improper) int { return improper.ImproperID })
+	for copyNumber := 0; copyNumber < copiesCount; copyNumber++ {
+		copyIndex := replication.copyIndex(copyNumber)
+		for _, atom := range lammpsStruct.Atoms {
+			result.Atoms = append(result.Atoms, replication.copyAtom(atom, copyIndex, copyNumber, maxMoleculeID))
+		}
+		for _, bond := range lammpsStruct.Bonds {
+			bond.BondID += copyNumber * maxBondID
+			bond.Ends = [2]int(replication.connect(bond.Ends[:], copyIndex))
+			result.Bonds = append(result.Bonds, bond)
+		}
+		for _, angle := range lammpsStruct.Angles {
+			angle.AngleID += copyNumber * maxAngleID
+			angle.Atoms = [3]int(replication.connect(angle.Atoms[:], copyIndex))
+			result.Angles = append(result.Angles, angle)
+		}
+		for _, dihedral := range lammpsStruct.Dihedrals {
+			dihedral.DihedralID += copyNumber * maxDihedralID
+			dihedral.Atoms = [4]int(replication.connect(dihedral.Atoms[:], copyIndex))
+			result.Dihedrals = append(result.Dihedrals, dihedral)
+		}
+		for _, improper := range lammpsStruct.Impropers {
+			improper.ImproperID += copyNumber * maxImproperID
+			improper.Atoms = [4]int(replication.connect(improper.Atoms[:], copyIndex))
+			result.Impropers = append(result.Impropers, improper)
+		}
+	}
+	return result, nil
+}
+
+// copyIndex returns the position of a copy along the box vectors; x changes the fastest.
+func (replication *_Replication) copyIndex(copyNumber int) [3]int {
+	counts := replication.counts
+	return [3]int{copyNumber % counts[0], copyNumber / counts[0] % counts[1], copyNumber / (counts[0] * counts[1])}
+}
+
+func (replication *_Replication) copyNumber(copyIndex [3]int) int {
+	counts := replication.counts
+	return copyIndex[0] + counts[0]*(copyIndex[1]+counts[1]*copyIndex[2])
+}
+
+/*
+copyAtom places the copy of an atom: the unwrapped atom is moved by the copy index
+along the box vectors and wrapped into the larger box, which sets the new image flags.
+*/
+func (replication *_Replication) copyAtom(atom Atom, copyIndex [3]int, copyNumber, maxMoleculeID int) Atom {
+	var slot [3]int
+	for axis := range slot {
+		unwrapped := atom.Image[axis] + copyIndex[axis]
+		slot[axis] = floorMod(unwrapped, replication.counts[axis])
+		atom.Image[axis] = floorDiv(unwrapped, replication.counts[axis])
+	}
+	atom.AtomCoords = replication.source.shift(atom.AtomCoords, slot)
+	atom.AtomID += copyNumber * replication.maxAtomID
+	if atom.MoleculeID != 0 {
+		atom.MoleculeID += copyNumber * maxMoleculeID
+	}
+	return atom
+}
+
+/*
+connect returns the IDs of the copies of the atoms of a bond, an angle, a dihedral or an improper
+in the copy of the first atom: every next atom is taken from the copy where it is the closest
+to the previous one, that is the same copy unless the image flags say otherwise.
+*/
+func (replication *_Replication) connect(atoms []int, copyIndex [3]int) []int {
+	result := make([]int, len(atoms))
+	current := copyIndex
+	for i, atomID := range atoms {
+		if i > 0 {
+			previous, atom := replication.atoms[atoms[i-1]], replication.atoms[atomID]
+			if previous != nil && atom != nil {
+				shift := replication.source.imageShift(previous, atom)
+				for axis := range current {
+					current[axis] = floorMod(current[axis]+previous.Image[axis]+shift[axis]-atom.Image[axis], replication.counts[axis])
+				}
+			}
+		}
+		result[i] = atomID + replication.copyNumber(current)*replication.maxAtomID
+	}
+	return result
+}
+
+// imageShift returns the periods to move the second atom by to get its minimum image next to the first one.
+func (lammpsStruct *LammpsStruct) imageShift(first, second *Atom) [3]int {
+	firstFractional, secondFractional := lammpsStruct.fractional(first.AtomCoords), lammpsStruct.fractional(second.AtomCoords)
+	var shift [3]int
+	for axis := range shift {
+		// a flat box has no periods along the axis
+		if difference := secondFractional[axis] - firstFractional[axis]; !math.IsNaN(difference) && !math.IsInf(difference, 0) {
+			shift[axis] = -int(math.Round(difference))
+		}
+	}
+	return shift
+}
+
+// shift moves the coordinates by whole box vectors.
+func (lammpsStruct *LammpsStruct) shift(coords AtomCoords, periods [3]int) AtomCoords {
+	lengths := boxLengths(lammpsStruct.SpaceDimention)
+	xy, xz, yz := 0.0, 0.0, 0.0
+	if lammpsStruct.Triclinic {
+		xy, xz, yz = lammpsStruct.TiltFactors[0], lammpsStruct.TiltFactors[1], lammpsStruct.TiltFactors[2]
+	}
+	a, b, c := float64(periods[0]), float64(periods[1]), float64(periods[2])
+	return AtomCoords{
+		X: coords.X + a*lengths[0] + b*xy + c*xz,
+		Y: coords.Y + b*lengths[1] + c*yz,
+		Z: coords.Z + c*lengths[2],
+	}
+}
+
+func floorDiv(a, b int) int {
+	quotient := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		quotient--
+	}
+	return quotient
+}
+
+func floorMod(a, b int) int {
+	return a - floorDiv(a, b)*b
+}
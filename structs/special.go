@@ -0,0 +1,124 @@
+package structs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+Specials are the special neighbours of the atoms, like LAMMPS computes them for special_bonds:
+the 1-2 neighbours are bonded to an atom, the 1-3 ones are two bonds away and the 1-4 ones are
+three bonds away. An atom is in one list only, the closest one, so in a ring the same atom is
+not both a 1-3 and a 1-4 neighbour.
+*/
+type Specials struct {
+	// OneTwo, OneThree and OneFour map the atom IDs to the IDs of their neighbours in the ascending order
+	OneTwo   map[int][]int
+	OneThree map[int][]int
+	OneFour  map[int][]int
+	// MaxOneTwo, MaxOneThree and MaxOneFour are the largest numbers of the neighbours of an atom
+	MaxOneTwo   int
+	MaxOneThree int
+	MaxOneFour  int
+	// MaxSpecial is the largest number of all the special neighbours of an atom; the
+	// "extra/special/per/atom" setting must let LAMMPS keep that many
+	MaxSpecial int
+	// ids are the atom IDs in the order of the structure
+	ids []int
+}
+
+// Specials computes the 1-2, 1-3 and 1-4 neighbours of the atoms from the bonds.
+func (lammpsStruct *LammpsStruct) Specials() *Specials {
+	graph := lammpsStruct.Graph()
+	specials := &Specials{
+		OneTwo:   make(map[int][]int, len(graph.ids)),
+		OneThree: make(map[int][]int, len(graph.ids)),
+		OneFour:  make(map[int][]int, len(graph.ids)),
+		ids:      slices.Clone(graph.ids),
+	}
+	// distances are reset only for the visited vertices, so the search stays local
+	distances := make([]int, len(graph.ids))
+	for start := range graph.ids {
+		lists := [3][]int{}
+		visited := []int{start}
+		distances[start] = -1
+		for i := 0; i < len(visited); i++ {
+			vertex := visited[i]
+			distance := max(distances[vertex], 0)
+			if distance == 3 {
+				continue
+			}
+			for _, neighbour := range graph.neighbours[vertex] {
+				if distances[neighbour] != 0 {
+					continue
+				}
+				distances[neighbour] = distance + 1
+				visited = append(visited, neighbour)
+				lists[distance] = append(lists[distance], graph.ids[neighbour])
+			}
+		}
+		for _, vertex := range visited {
+			distances[vertex] = 0
+		}
+
+		id := graph.ids[start]
+		for i := range lists {
+			slices.Sort(lists[i])
+		}
+		specials.OneTwo[id], specials.OneThree[id], specials.OneFour[id] = lists[0], lists[1], lists[2]
+		specials.MaxOneTwo = max(specials.MaxOneTwo, len(lists[0]))
+		specials.MaxOneThree = max(specials.MaxOneThree, len(lists[1]))
+		specials.MaxOneFour = max(specials.MaxOneFour, len(lists[2]))
+		specials.MaxSpecial = max(specials.MaxSpecial, len(lists[0])+len(lists[1])+len(lists[2]))
+	}
+	return specials
+}
+
+/*
+Separation tells how special two atoms are to each other.
+
+Returns:
+  - int: 1, 2 or 3 bonds for the 1-2, 1-3 and 1-4 neighbours, or 0 if the atoms are not special
+*/
+func (specials *Specials) Separation(first, second int) int {
+	for i, lists := range [3]map[int][]int{specials.OneTwo, specials.OneThree, specials.OneFour} {
+		if _, found := slices.BinarySearch(lists[first], second); found {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+/*
+MoleculeSections writes the "Special Bond Counts" and "Special Bonds" sections of a LAMMPS
+molecule file. The atoms are numbered in the file from 1 in the order of the structure, like in
+the other sections of a molecule file, so the structure is expected to be one molecule with IDs 1..N.
+
+Returns:
+  - string: the sections
+  - error: an atom ID that is not the number of the atom in the molecule file
+*/
+func (specials *Specials) MoleculeSections() (string, error) {
+	for i, id := range specials.ids {
+		if id != i+1 {
+			return "", fmt.Errorf("the atom %d is the atom %d of the molecule; renumber the atoms from 1", id, i+1)
+		}
+	}
+	builder := strings.Builder{}
+	builder.WriteString("Special Bond Counts\n\n")
+	for _, id := range specials.ids {
+		builder.WriteString(fmt.Sprintf("%d %d %d %d\n", id, len(specials.OneTwo[id]), len(specials.OneThree[id]), len(specials.OneFour[id])))
+	}
+	builder.WriteString("\nSpecial Bonds\n\n")
+	for _, id := range specials.ids {
+		builder.WriteString(fmt.Sprintf("%d", id))
+		for _, neighbours := range [3][]int{specials.OneTwo[id], specials.OneThree[id], specials.OneFour[id]} {
+			for _, neighbour := range neighbours {
+				builder.WriteString(fmt.Sprintf(" %d", neighbour))
+			}
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,203 @@
+package structs
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// SubsetOptions set how Subset numbers the types.
+type SubsetOptions struct {
+	// CompactTypes keeps only the types used in the subset and renumbers them from 1.
+	// Otherwise all the types are kept with their numbers, so they still match the force field.
+	CompactTypes bool
+}
+
+// SubsetMapping maps the new IDs and type numbers of a subset to the original ones.
+type SubsetMapping struct {
+	Atoms     map[int]int
+	Molecules map[int]int
+	Bonds     map[int]int
+	Angles    map[int]int
+	Dihedrals map[int]int
+	Impropers map[int]int
+	// the type maps are filled only when the types are compacted
+	AtomTypes     map[int]int
+	BondTypes     map[int]int
+	AngleTypes    map[int]int
+	DihedralTypes map[int]int
+	ImproperTypes map[int]int
+}
+
+/*
+Subset returns a new structure with the given atoms and only the bonds, angles, dihedrals
+and impropers whose atoms are all in the subset. The atoms, molecules, bonds, angles,
+dihedrals and impropers are renumbered from 1 in the order of their original IDs.
+
+Params:
+  - ids: the IDs of the atoms to keep, e.g. from a selection
+  - options: how to number the types
+
+Returns:
+  - LammpsStruct: the subset
+  - SubsetMapping: the original IDs and types of the subset
+  - error: an ID of a missing atom
+*/
+func (lammpsStruct *LammpsStruct) Subset(ids []int, options SubsetOptions) (*LammpsStruct, *SubsetMapping, error) {
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	for _, atom := range lammpsStruct.Atoms {
+		delete(selected, atom.AtomID)
+	}
+	if len(selected) != 0 {
+		return nil, nil, fmt.Errorf("the atoms %v do not exist", slices.Sorted(maps.Keys(selected)))
+	}
+	for _, id := range ids {
+		selected[id] = true
+	}
+	result := lammpsStruct.withAtoms(selected)
+	mapping := result.renumberIDs()
+
+	if !options.CompactTypes {
+		return result, mapping, nil
+	}
+	atomTypes := renumber(result.Atoms, func(atom *Atom) *int { return &atom.AtomType }, &mapping.AtomTypes)
+	result.AtomTypes = compactTypes(lammpsStruct.AtomTypes, atomTypes, func(atomType *AtomType) *int { return &atomType.AtomType })
+	bondTypes := renumber(result.Bonds, func(bond *Bond) *int { return &bond.ConnectionType }, &mapping.BondTypes)
+	result.BondTypes = compactTypes(lammpsStruct.BondTypes, bondTypes, func(bondType *BondType) *int { return &bondType.BondID })
+	angleTypes := renumber(result.Angles, func(angle *Angle) *int { return &angle.AngleType }, &mapping.AngleTypes)
+	result.AngleTypes = compactTypes(lammpsStruct.AngleTypes, angleTypes, func(angleType *AngleType) *int { return &angleType.AngleID })
+	dihedralTypes := renumber(result.Dihedrals, func(dihedral *Dihedral) *int { return &dihedral.DihedralType }, &mapping.DihedralTypes)
+	result.DihedralTypes = compactTypes(lammpsStruct.DihedralTypes, dihedralTypes, func(dihedralType *DihedralType) *int { return &dihedralType.DihedralID })
+	improperTypes := renumber(result.Impropers, func(improper *Improper) *int { return &improper.ImproperType }, &mapping.ImproperTypes)
+	result.ImproperTypes = compactTypes(lammpsStruct.ImproperTypes, improperTypes, func(improperType *ImproperType) *int { return &improperType.ImproperID })
+	return result, mapping, nil
+}
+
+/*
+withAtoms returns a copy of the structure with the selected atoms and the bonds, angles,
+dihedrals and impropers whose atoms are all selected. The types are kept as they are.
+*/
+func (lammpsStruct *LammpsStruct) withAtoms(selected map[int]bool) *LammpsStruct {
+	inside := func(atoms []int) bool {
+		for _, atom := range atoms {
+			if !selected[atom] {
+				return false
+			}
+		}
+		return true
+	}
+
+	result := &LammpsStruct{
+		FileName:       lammpsStruct.FileName,
+		Units:          lammpsStruct.Units,
+		AtomTypes:      slices.Clone(lammpsStruct.AtomTypes),
+		BondTypes:      slices.Clone(lammpsStruct.BondTypes),
+		AngleTypes:     slices.Clone(lammpsStruct.AngleTypes),
+		DihedralTypes:  slices.Clone(lammpsStruct.DihedralTypes),
+		ImproperTypes:  slices.Clone(lammpsStruct.ImproperTypes),
+		SpaceDimention: lammpsStruct.SpaceDimention,
+		Triclinic:      lammpsStruct.Triclinic,
+		TiltFactors:    lammpsStruct.TiltFactors,
+		Boundary:       lammpsStruct.Boundary,
+	}
+	for _, atom := range lammpsStruct.Atoms {
+		if selected[atom.AtomID] {
+			result.Atoms = append(result.Atoms, atom)
+		}
+	}
+	for _, bond := range lammpsStruct.Bonds {
+		if inside(bond.Ends[:]) {
+			result.Bonds = append(result.Bonds, bond)
+		}
+	}
+	for _, angle := range lammpsStruct.Angles {
+		if inside(angle.Atoms[:]) {
+			result.Angles = append(result.Angles, angle)
+		}
+	}
+	for _, dihedral := range lammpsStruct.Dihedrals {
+		if inside(dihedral.Atoms[:]) {
+			result.Dihedrals = append(result.Dihedrals, dihedral)
+		}
+	}
+	for _, improper := range lammpsStruct.Impropers {
+		if inside(improper.Atoms[:]) {
+			result.Impropers = append(result.Impropers, improper)
+		}
+	}
+	return result
+}
+
+// renumberIDs renumbers the atoms, molecules, bonds, angles, dihedrals and impropers from 1.
+func (lammpsStruct *LammpsStruct) renumberIDs() *SubsetMapping {
+	mapping := &SubsetMapping{}
+	atomIDs := renumber(lammpsStruct.Atoms, func(atom *Atom) *int { return &atom.AtomID }, &mapping.Atoms)
+	renumber(lammpsStruct.Atoms, func(atom *Atom) *int { return &atom.MoleculeID }, &mapping.Molecules)
+	renumber(lammpsStruct.Bonds, func(bond *Bond) *int { return &bond.BondID }, &mapping.Bonds)
+	renumber(lammpsStruct.Angles, func(angle *Angle) *int { return &angle.AngleID }, &mapping.Angles)
+	renumber(lammpsStruct.Dihedrals, func(dihedral *Dihedral) *int { return &dihedral.DihedralID }, &mapping.Dihedrals)
+	renumber(lammpsStruct.Impropers, func(improper *Improper) *int { return &improper.ImproperID }, &mapping.Impropers)
+	slices.SortStableFunc(lammpsStruct.Atoms, func(a1, a2 Atom) int { return a1.AtomID - a2.AtomID })
+	for i := range lammpsStruct.Bonds {
+		bond := &lammpsStruct.Bonds[i]
+		bond.Ends = [2]int{atomIDs[bond.Ends[0]], atomIDs[bond.Ends[1]]}
+	}
+	for i := range lammpsStruct.Angles {
+		for j, atom := range lammpsStruct.Angles[i].Atoms {
+			lammpsStruct.Angles[i].Atoms[j] = atomIDs[atom]
+		}
+	}
+	for i := range lammpsStruct.Dihedrals {
+		for j, atom := range lammpsStruct.Dihedrals[i].Atoms {
+			lammpsStruct.Dihedrals[i].Atoms[j] = atomIDs[atom]
+		}
+	}
+	for i := range lammpsStruct.Impropers {
+		for j, atom := range lammpsStruct.Impropers[i].Atoms {
+			lammpsStruct.Impropers[i].Atoms[j] = atomIDs[atom]
+		}
+	}
+	return mapping
+}
+
+/*
+renumber replaces the numbers of the items (the ones the field points to) by 1, 2, ...
+in their ascending order; zero numbers are kept. It fills the mapping from the new numbers
+to the original ones and returns the mapping from the original numbers to the new ones.
+*/
+func renumber[T any](items []T, field func(*T) *int, mapping *map[int]int) map[int]int {
+	used := make(map[int]bool)
+	for i := range items {
+		if number := *field(&items[i]); number != 0 {
+			used[number] = true
+		}
+	}
+	numbers := make(map[int]int, len(used))
+	*mapping = make(map[int]int, len(used))
+	for i, original := range slices.Sorted(maps.Keys(used)) {
+		numbers[original] = i + 1
+		(*mapping)[i+1] = original
+	}
+	for i := range items {
+		if number, ok := numbers[*field(&items[i])]; ok {
+			*field(&items[i]) = number
+		}
+	}
+	return numbers
+}
+
+// compactTypes keeps the types present in the numbers and gives them the new numbers.
+func compactTypes[T any](types []T, numbers map[int]int, number func(*T) *int) []T {
+	result := make([]T, 0, len(numbers))
+	for _, item := range types {
+		if newNumber, ok := numbers[*number(&item)]; ok {
+			*number(&item) = newNumber
+			result = append(result, item)
+		}
+	}
+	slices.SortFunc(result, func(t1, t2 T) int { return *number(&t1) - *number(&t2) })
+	return result
+}
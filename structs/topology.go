@@ -0,0 +1,232 @@
+package structs
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ANY_ATOM matches any atom in a TopologyRule.
+const ANY_ATOM = "*"
+
+/*
+TopologyRule gives a type to the generated angles, dihedrals or impropers whose atoms match it.
+Each pattern is an atom type number (e.g. "2"), a label (e.g. "C") or ANY_ATOM.
+An angle or a dihedral matches also in the reversed order; an improper matches with the
+central atom first and the other three in any order, which are then put in the order of the rule.
+*/
+type TopologyRule struct {
+	Atoms []string
+	Type  int
+}
+
+// TopologyOptions set what GenerateTopology generates and how the types are assigned.
+type TopologyOptions struct {
+	// the rules are tried in their order and the first matching one gives the type
+	AngleRules    []TopologyRule
+	DihedralRules []TopologyRule
+	ImproperRules []TopologyRule
+	// Impropers generates an improper around every atom with exactly three bonded atoms;
+	// otherwise the impropers are kept as they are
+	Impropers bool
+}
+
+/*
+GenerateTopology replaces the angles and dihedrals, and the impropers if asked, by the ones
+enumerated from the bonds. Every pair of bonds sharing an atom makes an angle, every bond with a
+bond on each side makes a dihedral unless they close a three-membered ring. The types missing in
+AngleTypes, DihedralTypes and ImproperTypes are declared without coefficients, and the header
+counts of a loaded file are updated.
+
+Params:
+  - options: the rules for the types and whether to generate the impropers
+
+Returns:
+  - error: an angle, dihedral or improper matching no rule; the structure is not changed then
+*/
+func (lammpsStruct *LammpsStruct) GenerateTopology(options TopologyOptions) error {
+	generator := newTopologyGenerator(lammpsStruct)
+	angles, err := generator.angles(options.AngleRules)
+	if err != nil {
+		return err
+	}
+	dihedrals, err := generator.dihedrals(options.DihedralRules)
+	if err != nil {
+		return err
+	}
+	impropers := lammpsStruct.Impropers
+	if options.Impropers {
+		if impropers, err = generator.impropers(options.ImproperRules); err != nil {
+			return err
+		}
+	}
+
+	lammpsStruct.Angles, lammpsStruct.Dihedrals, lammpsStruct.Impropers = angles, dihedrals, impropers
+	for _, angle := range angles {
+		lammpsStruct.AngleTypes = declareType(lammpsStruct.AngleTypes, angle.AngleType,
+			func(angleType *AngleType) *int { return &angleType.AngleID })
+	}
+	for _, dihedral := range dihedrals {
+		lammpsStruct.DihedralTypes = declareType(lammpsStruct.DihedralTypes, dihedral.DihedralType,
+			func(dihedralType *DihedralType) *int { return &dihedralType.DihedralID })
+	}
+	for _, improper := range impropers {
+		lammpsStruct.ImproperTypes = declareType(lammpsStruct.ImproperTypes, improper.ImproperType,
+			func(improperType *ImproperType) *int { return &improperType.ImproperID })
+	}
+	if lammpsStruct.HeaderCounts != nil {
+		counts := lammpsStruct.HeaderCounts
+		counts["angles"], counts["dihedrals"], counts["impropers"] = len(angles), len(dihedrals), len(impropers)
+		counts["angle types"] = max(counts["angle types"], len(lammpsStruct.AngleTypes))
+		counts["dihedral types"] = max(counts["dihedral types"], len(lammpsStruct.DihedralTypes))
+		counts["improper types"] = max(counts["improper types"], len(lammpsStruct.ImproperTypes))
+	}
+	return nil
+}
+
+type _TopologyGenerator struct {
+	graph *Graph
+	// patterns are the type numbers and the labels of the atoms by their IDs
+	types  map[int]string
+	labels map[int]string
+}
+
+func newTopologyGenerator(lammpsStruct *LammpsStruct) *_TopologyGenerator {
+	generator := &_TopologyGenerator{
+		graph:  lammpsStruct.Graph(),
+		types:  make(map[int]string, len(lammpsStruct.Atoms)),
+		labels: make(map[int]string, len(lammpsStruct.Atoms)),
+	}
+	typeLabels := make(map[int]string, len(lammpsStruct.AtomTypes))
+	for _, atomType := range lammpsStruct.AtomTypes {
+		typeLabels[atomType.AtomType] = atomType.AtomLabel
+	}
+	for _, atom := range lammpsStruct.Atoms {
+		generator.types[atom.AtomID] = strconv.Itoa(atom.AtomType)
+		generator.labels[atom.AtomID] = atom.Label
+		if len(atom.Label) == 0 {
+			generator.labels[atom.AtomID] = typeLabels[atom.AtomType]
+		}
+	}
+	return generator
+}
+
+func (generator *_TopologyGenerator) angles(rules []TopologyRule) ([]Angle, error) {
+	angles := make([]Angle, 0)
+	for _, vertex := range generator.graph.ids {
+		neighbours := generator.graph.Neighbours(vertex)
+		for i, first := range neighbours {
+			for _, third := range neighbours[i+1:] {
+				atoms := [3]int{first, vertex, third}
+				angleType, err := generator.reversibleType(rules, atoms[:], "angle")
+				if err != nil {
+					return nil, err
+				}
+				angles = append(angles, Angle{AngleID: len(angles) + 1, AngleType: angleType, Atoms: atoms})
+			}
+		}
+	}
+	return angles, nil
+}
+
+func (generator *_TopologyGenerator) dihedrals(rules []TopologyRule) ([]Dihedral, error) {
+	dihedrals := make([]Dihedral, 0)
+	for _, second := range generator.graph.ids {
+		for _, third := range generator.graph.Neighbours(second) {
+			if third < second {
+				continue
+			}
+			for _, first := range generator.graph.Neighbours(second) {
+				for _, fourth := range generator.graph.Neighbours(third) {
+					if first == third || fourth == second || first == fourth {
+						continue
+					}
+					atoms := [4]int{first, second, third, fourth}
+					dihedralType, err := generator.reversibleType(rules, atoms[:], "dihedral")
+					if err != nil {
+						return nil, err
+					}
+					dihedrals = append(dihedrals, Dihedral{DihedralID: len(dihedrals) + 1, DihedralType: dihedralType, Atoms: atoms})
+				}
+			}
+		}
+	}
+	return dihedrals, nil
+}
+
+func (generator *_TopologyGenerator) impropers(rules []TopologyRule) ([]Improper, error) {
+	impropers := make([]Improper, 0)
+	for _, center := range generator.graph.ids {
+		neighbours := generator.graph.Neighbours(center)
+		if len(neighbours) != 3 {
+			continue
+		}
+		orders := [][3]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+		found := false
+		for _, rule := range rules {
+			for _, order := range orders {
+				atoms := [4]int{center, neighbours[order[0]], neighbours[order[1]], neighbours[order[2]]}
+				if generator.matches(rule, atoms[:]) {
+					impropers = append(impropers, Improper{ImproperID: len(impropers) + 1, ImproperType: rule.Type, Atoms: atoms})
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return nil, generator.unmatched("improper", []int{center, neighbours[0], neighbours[1], neighbours[2]})
+		}
+	}
+	return impropers, nil
+}
+
+// reversibleType returns the type of the first rule matching the atoms in the straight or reversed order.
+func (generator *_TopologyGenerator) reversibleType(rules []TopologyRule, atoms []int, kind string) (int, error) {
+	reversed := slices.Clone(atoms)
+	slices.Reverse(reversed)
+	for _, rule := range rules {
+		if generator.matches(rule, atoms) || generator.matches(rule, reversed) {
+			return rule.Type, nil
+		}
+	}
+	return 0, generator.unmatched(kind, atoms)
+}
+
+func (generator *_TopologyGenerator) matches(rule TopologyRule, atoms []int) bool {
+	if len(rule.Atoms) != len(atoms) {
+		return false
+	}
+	for i, pattern := range rule.Atoms {
+		if pattern != ANY_ATOM && pattern != generator.types[atoms[i]] && pattern != generator.labels[atoms[i]] {
+			return false
+		}
+	}
+	return true
+}
+
+func (generator *_TopologyGenerator) unmatched(kind string, atoms []int) error {
+	patterns := make([]string, len(atoms))
+	for i, atom := range atoms {
+		patterns[i] = generator.types[atom]
+		if label := generator.labels[atom]; len(label) != 0 {
+			patterns[i] = label
+		}
+	}
+	return fmt.Errorf("no rule for the %s %v of the atoms %s", kind, atoms, strings.Join(patterns, "-"))
+}
+
+// declareType adds the type number to the types, keeping them sorted, if it is not there.
+func declareType[T any](types []T, typeNumber int, number func(*T) *int) []T {
+	if slices.ContainsFunc(types, func(item T) bool { return *number(&item) == typeNumber }) {
+		return types
+	}
+	var declared T
+	*number(&declared) = typeNumber
+	types = append(types, declared)
+	slices.SortStableFunc(types, func(t1, t2 T) int { return *number(&t1) - *number(&t2) })
+	return types
+}
@@ -0,0 +1,388 @@
+package structs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+/*
+RotationFromAxisAngle returns the matrix of the rotation around an axis.
+
+Params:
+  - axis: the direction of the axis; its length does not matter
+  - angle: the angle in degrees, counterclockwise looking against the axis
+
+Returns:
+  - [3][3]float64: the rotation matrix
+*/
+func RotationFromAxisAngle(axis AtomCoords, angle float64) [3][3]float64 {
+	length := math.Sqrt(axis.X*axis.X + axis.Y*axis.Y + axis.Z*axis.Z)
+	if length == 0 {
+		return identity()
+	}
+	x, y, z := axis.X/length, axis.Y/length, axis.Z/length
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	return [3][3]float64{
+		{cos + x*x*(1-cos), x*y*(1-cos) - z*sin, x*z*(1-cos) + y*sin},
+		{y*x*(1-cos) + z*sin, cos + y*y*(1-cos), y*z*(1-cos) - x*sin},
+		{z*x*(1-cos) - y*sin, z*y*(1-cos) + x*sin, cos + z*z*(1-cos)},
+	}
+}
+
+/*
+RotationFromQuaternion returns the matrix of the rotation given by a quaternion.
+
+Params:
+  - quaternion: w, x, y, z; it is normalized
+
+Returns:
+  - [3][3]float64: the rotation matrix
+*/
+func RotationFromQuaternion(quaternion [4]float64) [3][3]float64 {
+	w, x, y, z := quaternion[0], quaternion[1], quaternion[2], quaternion[3]
+	norm := math.Sqrt(w*w + x*x + y*y + z*z)
+	if norm == 0 {
+		return identity()
+	}
+	w, x, y, z = w/norm, x/norm, y/norm, z/norm
+	return [3][3]float64{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}
+}
+
+/*
+Translate moves the atoms by a vector like the LAMMPS "displace_atoms move" command: the box
+stays and the atoms leaving it are wrapped back with their image flags changed.
+*/
+func (lammpsStruct *LammpsStruct) Translate(delta AtomCoords) {
+	for i := range lammpsStruct.Atoms {
+		lammpsStruct.Atoms[i].AtomCoords = addCoords(lammpsStruct.Atoms[i].AtomCoords, delta)
+	}
+	lammpsStruct.Wrap()
+}
+
+// CenterAt moves the atoms together with the box so the center of the unwrapped atoms is at the point, e.g. at the origin.
+func (lammpsStruct *LammpsStruct) CenterAt(point AtomCoords) {
+	delta := subCoords(point, lammpsStruct.center())
+	for i := range lammpsStruct.Atoms {
+		lammpsStruct.Atoms[i].AtomCoords = addCoords(lammpsStruct.Atoms[i].AtomCoords, delta)
+	}
+	for axis, shift := range [3]float64{delta.X, delta.Y, delta.Z} {
+		lammpsStruct.SpaceDimention[axis][0] += shift
+		lammpsStruct.SpaceDimention[axis][1] += shift
+	}
+}
+
+// CenterInBox translates the atoms so the center of the unwrapped atoms is at the center of the box.
+func (lammpsStruct *LammpsStruct) CenterInBox() {
+	lammpsStruct.Translate(subCoords(lammpsStruct.boxCenter(), lammpsStruct.center()))
+}
+
+/*
+Rotate rotates the unwrapped atoms around a point and wraps them back into the box. The box is
+not rotated, as a LAMMPS box has its first edge along x, so a rotated crystal loses its periodic
+images; the rotation is meant for molecules in a box larger than them.
+
+Params:
+  - matrix: the rotation matrix applied to the column vectors, e.g. from RotationFromAxisAngle
+  - center: the fixed point
+
+Returns:
+  - error: the matrix is not a rotation
+*/
+func (lammpsStruct *LammpsStruct) Rotate(matrix [3][3]float64, center AtomCoords) error {
+	for i := range matrix {
+		for j := range matrix {
+			dot := 0.0
+			for k := range matrix {
+				dot += matrix[i][k] * matrix[j][k]
+			}
+			if expected := boolToFloat(i == j); math.Abs(dot-expected) > 1e-6 {
+				return errors.New("the matrix is not orthonormal")
+			}
+		}
+	}
+	if determinant(matrix) < 0 {
+		return errors.New("the matrix is a reflection, not a rotation")
+	}
+	lammpsStruct.transformUnwrapped(matrix, center)
+	lammpsStruct.Wrap()
+	return nil
+}
+
+/*
+Reflect mirrors the atoms in the plane perpendicular to an axis through the center of the box.
+The box keeps its bounds; the tilt factors and the image flags along the axis change their signs,
+so the periodic images are mirrored as well.
+
+Params:
+  - axis: DIMENTION_TYPE_X, DIMENTION_TYPE_Y or DIMENTION_TYPE_Z
+
+Returns:
+  - error: a wrong axis
+*/
+func (lammpsStruct *LammpsStruct) Reflect(axis DimentionType) error {
+	if axis < DIMENTION_TYPE_X || axis > DIMENTION_TYPE_Z {
+		return fmt.Errorf("wrong axis %d", axis)
+	}
+	middle := (lammpsStruct.SpaceDimention[axis][0] + lammpsStruct.SpaceDimention[axis][1]) / 2
+	for i := range lammpsStruct.Atoms {
+		atom := &lammpsStruct.Atoms[i]
+		coords := [3]float64{atom.X, atom.Y, atom.Z}
+		coords[axis] = 2*middle - coords[axis]
+		atom.AtomCoords = AtomCoords{X: coords[0], Y: coords[1], Z: coords[2]}
+		atom.Image[axis] = -atom.Image[axis]
+	}
+	// the tilt factors xy, xz and yz couple the axes x-y, x-z and y-z
+	couplings := [3][2]DimentionType{{DIMENTION_TYPE_X, DIMENTION_TYPE_Y}, {DIMENTION_TYPE_X, DIMENTION_TYPE_Z}, {DIMENTION_TYPE_Y, DIMENTION_TYPE_Z}}
+	for i, coupled := range couplings {
+		if coupled[0] == axis || coupled[1] == axis {
+			lammpsStruct.TiltFactors[i] = -lammpsStruct.TiltFactors[i]
+		}
+	}
+	// a mirrored triclinic box is shifted against the bounds, so the atoms are put back into it
+	lammpsStruct.Wrap()
+	return nil
+}
+
+/*
+Scale stretches the box and the atoms with it around the center of the box, like the LAMMPS
+"change_box ... scale ... remap" command. The fractional coordinates and the image flags stay
+the same; the tilt factors are stretched as the box edges.
+
+Params:
+  - factors: the factors along x, y and z
+
+Returns:
+  - error: a non-positive factor
+*/
+func (lammpsStruct *LammpsStruct) Scale(factors [3]float64) error {
+	for _, factor := range factors {
+		if factor <= 0 {
+			return fmt.Errorf("the scale factors %v must be positive", factors)
+		}
+	}
+	center := lammpsStruct.boxCenter()
+	matrix := [3][3]float64{{factors[0], 0, 0}, {0, factors[1], 0}, {0, 0, factors[2]}}
+	for i := range lammpsStruct.Atoms {
+		atom := &lammpsStruct.Atoms[i]
+		atom.AtomCoords = addCoords(center, applyMatrix(matrix, subCoords(atom.AtomCoords, center)))
+	}
+	for axis, middle := range [3]float64{center.X, center.Y, center.Z} {
+		for bound := range lammpsStruct.SpaceDimention[axis] {
+			lammpsStruct.SpaceDimention[axis][bound] = middle + (lammpsStruct.SpaceDimention[axis][bound]-middle)*factors[axis]
+		}
+	}
+	// the edge b = (xy, ly, 0) stretches its x part with x, and c = (xz, yz, lz) its x and y parts
+	lammpsStruct.TiltFactors[0] *= factors[0]
+	lammpsStruct.TiltFactors[1] *= factors[0]
+	lammpsStruct.TiltFactors[2] *= factors[1]
+	return nil
+}
+
+/*
+AlignPrincipalAxes rotates the unwrapped atoms around their center of mass so that their
+principal axes of inertia are along x, y and z, the axis with the smallest moment (the longest
+extent) along x. The atoms are wrapped back into the box, which is not rotated.
+*/
+func (lammpsStruct *LammpsStruct) AlignPrincipalAxes() {
+	masses := lammpsStruct.atomMasses()
+	positions := make([]AtomCoords, len(lammpsStruct.Atoms))
+	for i := range lammpsStruct.Atoms {
+		positions[i] = lammpsStruct.unwrapped(&lammpsStruct.Atoms[i])
+	}
+	center := massCenter(positions, masses)
+	_, axes := symmetricEigen(inertiaTensor(positions, masses, center))
+	// the rows of the rotation are the principal axes; the last one completes a right-handed basis
+	rotation := [3][3]float64{
+		{axes[0].X, axes[0].Y, axes[0].Z},
+		{axes[1].X, axes[1].Y, axes[1].Z},
+	}
+	third := crossCoords(axes[0], axes[1])
+	rotation[2] = [3]float64{third.X, third.Y, third.Z}
+	lammpsStruct.transformUnwrapped(rotation, center)
+	lammpsStruct.Wrap()
+}
+
+// transformUnwrapped applies a matrix to the unwrapped atoms around a point and clears the image flags.
+func (lammpsStruct *LammpsStruct) transformUnwrapped(matrix [3][3]float64, center AtomCoords) {
+	for i := range lammpsStruct.Atoms {
+		atom := &lammpsStruct.Atoms[i]
+		atom.AtomCoords = addCoords(center, applyMatrix(matrix, subCoords(lammpsStruct.unwrapped(atom), center)))
+		atom.Image = [3]int{}
+	}
+}
+
+// center returns the geometric center of the unwrapped atoms.
+func (lammpsStruct *LammpsStruct) center() AtomCoords {
+	positions := make([]AtomCoords, len(lammpsStruct.Atoms))
+	for i := range lammpsStruct.Atoms {
+		positions[i] = lammpsStruct.unwrapped(&lammpsStruct.Atoms[i])
+	}
+	return massCenter(positions, nil)
+}
+
+// boxCenter returns the center of the box; a triclinic box is shifted by the halves of the tilts.
+func (lammpsStruct *LammpsStruct) boxCenter() AtomCoords {
+	box := lammpsStruct.SpaceDimention
+	center := AtomCoords{X: (box[0][0] + box[0][1]) / 2, Y: (box[1][0] + box[1][1]) / 2, Z: (box[2][0] + box[2][1]) / 2}
+	if lammpsStruct.Triclinic {
+		center.X += (lammpsStruct.TiltFactors[0] + lammpsStruct.TiltFactors[1]) / 2
+		center.Y += lammpsStruct.TiltFactors[2] / 2
+	}
+	return center
+}
+
+// atomMasses returns the masses of the atoms by their types; all of them are 1 when the masses are unknown.
+func (lammpsStruct *LammpsStruct) atomMasses() []float64 {
+	typeMasses := make(map[int]float64, len(lammpsStruct.AtomTypes))
+	for _, atomType := range lammpsStruct.AtomTypes {
+		typeMasses[atomType.AtomType] = atomType.AtomMass
+	}
+	masses := make([]float64, len(lammpsStruct.Atoms))
+	for i, atom := range lammpsStruct.Atoms {
+		mass, ok := typeMasses[atom.AtomType]
+		if !ok || mass <= 0 {
+			mass = 1
+		}
+		masses[i] = mass
+	}
+	return masses
+}
+
+// massCenter returns the center of mass of the positions, or their geometric center without the masses.
+func massCenter(positions []AtomCoords, masses []float64) AtomCoords {
+	var center AtomCoords
+	total := 0.0
+	for i, position := range positions {
+		mass := 1.0
+		if masses != nil {
+			mass = masses[i]
+		}
+		center = addCoords(center, scaleCoords(position, mass))
+		total += mass
+	}
+	if total == 0 {
+		return center
+	}
+	return scaleCoords(center, 1/total)
+}
+
+// inertiaTensor returns the moment of inertia tensor of the positions around the center.
+func inertiaTensor(positions []AtomCoords, masses []float64, center AtomCoords) [3][3]float64 {
+	var tensor [3][3]float64
+	for i, position := range positions {
+		relative := subCoords(position, center)
+		r := [3]float64{relative.X, relative.Y, relative.Z}
+		squared := r[0]*r[0] + r[1]*r[1] + r[2]*r[2]
+		for j := range r {
+			for k := range r {
+				tensor[j][k] += masses[i] * (boolToFloat(j == k)*squared - r[j]*r[k])
+			}
+		}
+	}
+	return tensor
+}
+
+/*
+symmetricEigen finds the eigenvalues and the eigenvectors of a symmetric matrix by the Jacobi method.
+
+Returns:
+  - [3]float64: the eigenvalues in the ascending order
+  - [3]AtomCoords: the unit eigenvectors in the same order
+*/
+func symmetricEigen(matrix [3][3]float64) ([3]float64, [3]AtomCoords) {
+	vectors := identity()
+	for sweep := 0; sweep < 50; sweep++ {
+		offDiagonal := matrix[0][1]*matrix[0][1] + matrix[0][2]*matrix[0][2] + matrix[1][2]*matrix[1][2]
+		if offDiagonal < 1e-30 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if matrix[p][q] == 0 {
+					continue
+				}
+				theta := (matrix[q][q] - matrix[p][p]) / (2 * matrix[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				cos := 1 / math.Sqrt(t*t+1)
+				sin := t * cos
+				for k := 0; k < 3; k++ {
+					kp, kq := matrix[k][p], matrix[k][q]
+					matrix[k][p], matrix[k][q] = cos*kp-sin*kq, sin*kp+cos*kq
+				}
+				for k := 0; k < 3; k++ {
+					pk, qk := matrix[p][k], matrix[q][k]
+					matrix[p][k], matrix[q][k] = cos*pk-sin*qk, sin*pk+cos*qk
+				}
+				for k := 0; k < 3; k++ {
+					kp, kq := vectors[k][p], vectors[k][q]
+					vectors[k][p], vectors[k][q] = cos*kp-sin*kq, sin*kp+cos*kq
+				}
+			}
+		}
+	}
+	order := [3]int{0, 1, 2}
+	for i := 0; i < 3; i++ {
+		for j := i + 1; j < 3; j++ {
+			if matrix[order[j]][order[j]] < matrix[order[i]][order[i]] {
+				order[i], order[j] = order[j], order[i]
+			}
+		}
+	}
+	var values [3]float64
+	var result [3]AtomCoords
+	for i, column := range order {
+		values[i] = matrix[column][column]
+		result[i] = AtomCoords{X: vectors[0][column], Y: vectors[1][column], Z: vectors[2][column]}
+	}
+	return values, result
+}
+
+func identity() [3][3]float64 {
+	return [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+}
+
+func determinant(matrix [3][3]float64) float64 {
+	return matrix[0][0]*(matrix[1][1]*matrix[2][2]-matrix[1][2]*matrix[2][1]) -
+		matrix[0][1]*(matrix[1][0]*matrix[2][2]-matrix[1][2]*matrix[2][0]) +
+		matrix[0][2]*(matrix[1][0]*matrix[2][1]-matrix[1][1]*matrix[2][0])
+}
+
+func applyMatrix(matrix [3][3]float64, vector AtomCoords) AtomCoords {
+	return AtomCoords{
+		X: matrix[0][0]*vector.X + matrix[0][1]*vector.Y + matrix[0][2]*vector.Z,
+		Y: matrix[1][0]*vector.X + matrix[1][1]*vector.Y + matrix[1][2]*vector.Z,
+		Z: matrix[2][0]*vector.X + matrix[2][1]*vector.Y + matrix[2][2]*vector.Z,
+	}
+}
+
+func addCoords(first, second AtomCoords) AtomCoords {
+	return AtomCoords{X: first.X + second.X, Y: first.Y + second.Y, Z: first.Z + second.Z}
+}
+
+func subCoords(first, second AtomCoords) AtomCoords {
+	return AtomCoords{X: first.X - second.X, Y: first.Y - second.Y, Z: first.Z - second.Z}
+}
+
+func scaleCoords(coords AtomCoords, factor float64) AtomCoords {
+	return AtomCoords{X: coords.X * factor, Y: coords.Y * factor, Z: coords.Z * factor}
+}
+
+func crossCoords(first, second AtomCoords) AtomCoords {
+	return AtomCoords{
+		X: first.Y*second.Z - first.Z*second.Y,
+		Y: first.Z*second.X - first.X*second.Z,
+		Z: first.X*second.Y - first.Y*second.X,
+	}
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,281 @@
+package structs
+
+import (
+	"fmt"
+	"math"
+)
+
+type Severity = string
+
+const (
+	// SEVERITY_ERROR marks the findings that make the structure unusable in LAMMPS.
+	SEVERITY_ERROR Severity = "error"
+	// SEVERITY_WARNING marks the findings that are suspicious, but allowed.
+	SEVERITY_WARNING Severity = "warning"
+)
+
+// Finding is a problem found by Validate.
+type Finding struct {
+	Severity Severity
+	// Section is the data file section of the problem, e.g. "Atoms", or "Header" and "Box".
+	Section string
+	// ID is the ID of the atom, the bond, the type etc. the problem is about, or zero.
+	ID      int
+	Message string
+}
+
+func (finding Finding) String() string {
+	if finding.ID != 0 {
+		return fmt.Sprintf("%s: %s %d: %s", finding.Severity, finding.Section, finding.ID, finding.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", finding.Severity, finding.Section, finding.Message)
+}
+
+// ValidateOptions are the thresholds of the warnings of Validate.
+type ValidateOptions struct {
+	// MinBondLength and MaxBondLength bound the realistic bond lengths; zero disables the check.
+	MinBondLength float64
+	MaxBondLength float64
+	// ChargeTolerance is the largest net charge that is considered zero.
+	ChargeTolerance float64
+}
+
+/*
+DefaultValidateOptions returns the thresholds suitable for the unit style:
+bonds from 0.5 to 3 Å in the real and metal units and from 0.5 to 2 σ in the lj ones.
+The bond lengths are not checked for the other styles.
+*/
+func DefaultValidateOptions(units string) ValidateOptions {
+	options := ValidateOptions{ChargeTolerance: 1e-4}
+	switch units {
+	case "real", "metal":
+		options.MinBondLength, options.MaxBondLength = 0.5, 3
+	case "lj":
+		options.MinBondLength, options.MaxBondLength = 0.5, 2
+	}
+	return options
+}
+
+type _Validation struct {
+	lammpsStruct *LammpsStruct
+	options      ValidateOptions
+	findings     []Finding
+	atoms        map[int]*Atom
+}
+
+func (validation *_Validation) add(severity Severity, section string, id int, format string, a ...any) {
+	validation.findings = append(validation.findings, Finding{
+		Severity: severity,
+		Section:  section,
+		ID:       id,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+/*
+Validate checks the consistency of the structure: the box, the header counts against
+the sections, the duplicate IDs, the undeclared types, the masses, the topology referring
+to missing atoms, the atoms outside the box, the net charge and the bond lengths
+under the minimum image convention.
+
+Params:
+  - options: the thresholds of the warnings, see DefaultValidateOptions
+
+Returns:
+  - []Finding: the problems found, errors and warnings; empty for a consistent structure
+*/
+func (lammpsStruct *LammpsStruct) Validate(options ValidateOptions) []Finding {
+	validation := &_Validation{
+		lammpsStruct: lammpsStruct,
+		options:      options,
+		findings:     make([]Finding, 0),
+		atoms:        make(map[int]*Atom, len(lammpsStruct.Atoms)),
+	}
+	boxIsValid := validation.checkBox()
+	validation.checkHeaderCounts()
+
+	atomTypes := validation.checkTypes("Masses", "atom types", collectIDs(lammpsStruct.AtomTypes, func(atomType AtomType) int { return atomType.AtomType }))
+	for _, atomType := range lammpsStruct.AtomTypes {
+		if atomType.AtomMass <= 0 {
+			validation.add(SEVERITY_ERROR, "Masses", atomType.AtomType, "the mass must be positive, got %g", atomType.AtomMass)
+		}
+	}
+	validation.checkAtoms(atomTypes, boxIsValid)
+
+	bondTypes := validation.checkTypes("Bond Coeffs", "bond types", collectIDs(lammpsStruct.BondTypes, func(bondType BondType) int { return bondType.BondID }))
+	angleTypes := validation.checkTypes("Angle Coeffs", "angle types", collectIDs(lammpsStruct.AngleTypes, func(angleType AngleType) int { return angleType.AngleID }))
+	dihedralTypes := validation.checkTypes("Dihedral Coeffs", "dihedral types", collectIDs(lammpsStruct.DihedralTypes, func(dihedralType DihedralType) int { return dihedralType.DihedralID }))
+	improperTypes := validation.checkTypes("Improper Coeffs", "improper types", collectIDs(lammpsStruct.ImproperTypes, func(improperType ImproperType) int { return improperType.ImproperID }))
+
+	validation.checkIDs("Bonds", collectIDs(lammpsStruct.Bonds, func(bond Bond) int { return bond.BondID }))
+	for _, bond := range lammpsStruct.Bonds {
+		validation.checkTopology("Bonds", bond.BondID, bond.ConnectionType, bondTypes, bond.Ends[:])
+	}
+	validation.checkBondLengths()
+	validation.checkIDs("Angles", collectIDs(lammpsStruct.Angles, func(angle Angle) int { return angle.AngleID }))
+	for _, angle := range lammpsStruct.Angles {
+		validation.checkTopology("Angles", angle.AngleID, angle.AngleType, angleTypes, angle.Atoms[:])
+	}
+	validation.checkIDs("Dihedrals", collectIDs(lammpsStruct.Dihedrals, func(dihedral Dihedral) int { return dihedral.DihedralID }))
+	for _, dihedral := range lammpsStruct.Dihedrals {
+		validation.checkTopology("Dihedrals", dihedral.DihedralID, dihedral.DihedralType, dihedralTypes, dihedral.Atoms[:])
+	}
+	validation.checkIDs("Impropers", collectIDs(lammpsStruct.Impropers, func(improper Improper) int { return improper.ImproperID }))
+	for _, improper := range lammpsStruct.Impropers {
+		validation.checkTopology("Impropers", improper.ImproperID, improper.ImproperType, improperTypes, improper.Atoms[:])
+	}
+	return validation.findings
+}
+
+// HasErrors tells if any of the findings is an error.
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SEVERITY_ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+func (validation *_Validation) checkBox() bool {
+	isValid := true
+	axes := [3]string{"x", "y", "z"}
+	for i, bounds := range validation.lammpsStruct.SpaceDimention {
+		if bounds[0] >= bounds[1] {
+			validation.add(SEVERITY_ERROR, "Box", 0, "%slo %g must be less than %shi %g", axes[i], bounds[0], axes[i], bounds[1])
+			isValid = false
+		}
+	}
+	for i, style := range validation.lammpsStruct.Boundary {
+		if err := checkBoundaryStyle(style); err != nil {
+			validation.add(SEVERITY_ERROR, "Box", 0, "the %s boundary: %v", axes[i], err)
+		}
+	}
+	return isValid
+}
+
+func (validation *_Validation) checkHeaderCounts() {
+	lammpsStruct := validation.lammpsStruct
+	if lammpsStruct.HeaderCounts == nil {
+		return
+	}
+	counts := []struct {
+		name  string
+		count int
+	}{
+		{"atoms", len(lammpsStruct.Atoms)},
+		{"bonds", len(lammpsStruct.Bonds)},
+		{"angles", len(lammpsStruct.Angles)},
+		{"dihedrals", len(lammpsStruct.Dihedrals)},
+		{"impropers", len(lammpsStruct.Impropers)},
+	}
+	for _, count := range counts {
+		if declared := lammpsStruct.HeaderCounts[count.name]; declared != count.count {
+			validation.add(SEVERITY_ERROR, "Header", 0, "%d %s declared, %d found", declared, count.name, count.count)
+		}
+	}
+}
+
+// checkIDs reports the IDs that are not positive or repeated and returns the IDs as a set.
+func (validation *_Validation) checkIDs(section string, ids []int) map[int]bool {
+	set := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			validation.add(SEVERITY_ERROR, section, 0, "the ID must be positive, got %d", id)
+		} else if set[id] {
+			validation.add(SEVERITY_ERROR, section, id, "the ID is repeated")
+		}
+		set[id] = true
+	}
+	return set
+}
+
+/*
+checkTypes returns the declared types: the ones listed in the section and, for a loaded
+file, the numbers up to the count of the header (the coefficients may be set in the input script).
+*/
+func (validation *_Validation) checkTypes(section, headerName string, ids []int) map[int]bool {
+	types := validation.checkIDs(section, ids)
+	declared, ok := validation.lammpsStruct.HeaderCounts[headerName]
+	if !ok {
+		return types
+	}
+	for id := range types {
+		if id > declared {
+			validation.add(SEVERITY_ERROR, section, id, "the type is out of the %d %s of the header", declared, headerName)
+		}
+	}
+	for id := 1; id <= declared; id++ {
+		types[id] = true
+	}
+	return types
+}
+
+func (validation *_Validation) checkAtoms(atomTypes map[int]bool, boxIsValid bool) {
+	lammpsStruct := validation.lammpsStruct
+	validation.checkIDs("Atoms", collectIDs(lammpsStruct.Atoms, func(atom Atom) int { return atom.AtomID }))
+	charge := 0.0
+	for i := range lammpsStruct.Atoms {
+		atom := &lammpsStruct.Atoms[i]
+		validation.atoms[atom.AtomID] = atom
+		charge += atom.Q
+		if !atomTypes[atom.AtomType] {
+			validation.add(SEVERITY_ERROR, "Atoms", atom.AtomID, "the type %d is not declared", atom.AtomType)
+		}
+		if !boxIsValid {
+			continue
+		}
+		for _, coord := range lammpsStruct.fractional(atom.AtomCoords) {
+			if coord < 0 || coord >= 1 {
+				validation.add(SEVERITY_WARNING, "Atoms", atom.AtomID, "the atom (%g, %g, %g) is outside the box", atom.X, atom.Y, atom.Z)
+				break
+			}
+		}
+	}
+	if math.Abs(charge) > validation.options.ChargeTolerance {
+		validation.add(SEVERITY_WARNING, "Atoms", 0, "the net charge is %g", charge)
+	}
+}
+
+// checkTopology checks the type and the atoms of a bond, an angle, a dihedral or an improper.
+func (validation *_Validation) checkTopology(section string, id, topologyType int, types map[int]bool, atoms []int) {
+	if !types[topologyType] {
+		validation.add(SEVERITY_ERROR, section, id, "the type %d is not declared", topologyType)
+	}
+	seen := make(map[int]bool, len(atoms))
+	for _, atom := range atoms {
+		if validation.atoms[atom] == nil {
+			validation.add(SEVERITY_ERROR, section, id, "the atom %d does not exist", atom)
+		} else if seen[atom] {
+			validation.add(SEVERITY_ERROR, section, id, "the atom %d is used twice", atom)
+		}
+		seen[atom] = true
+	}
+}
+
+func (validation *_Validation) checkBondLengths() {
+	options := validation.options
+	if options.MinBondLength <= 0 && options.MaxBondLength <= 0 {
+		return
+	}
+	for _, bond := range validation.lammpsStruct.Bonds {
+		first, second := validation.atoms[bond.Ends[0]], validation.atoms[bond.Ends[1]]
+		if first == nil || second == nil {
+			continue
+		}
+		length := validation.lammpsStruct.Distance(&first.AtomCoords, &second.AtomCoords)
+		if (options.MinBondLength > 0 && length < options.MinBondLength) ||
+			(options.MaxBondLength > 0 && length > options.MaxBondLength) {
+			validation.add(SEVERITY_WARNING, "Bonds", bond.BondID, "the length %g between the atoms %d and %d is unrealistic",
+				length, bond.Ends[0], bond.Ends[1])
+		}
+	}
+}
+
+func collectIDs[T any](items []T, id func(T) int) []int {
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = id(item)
+	}
+	return ids
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Ivanestver/lammps-file-parser/deserialize"
+	"github.com/Ivanestver/lammps-file-parser/structs"
+)
+
+var validateCommand = _Command{
+	name:        "validate",
+	usage:       "[-units style] [-min-bond length] [-max-bond length] [-strict] [-json] [-o output] input",
+	description: "Check the consistency of a data file; exits with 1 if errors are found.",
+	run:         runValidate,
+}
+
+func runValidate(command *_Command, args []string) error {
+	flags := newFlagSet(command)
+	units := flags.String("units", "", "unit style for the default thresholds (taken from the file header by default)")
+	minBond := flags.Float64("min-bond", -1, "shortest realistic bond length (default by the unit style, 0 disables)")
+	maxBond := flags.Float64("max-bond", -1, "longest realistic bond length (default by the unit style, 0 disables)")
+	strict := flags.Bool("strict", false, "fail on warnings too")
+	asJSON := flags.Bool("json", false, "print JSON instead of text")
+	output := flags.String("o", stdio, "output file")
+	from := flags.String("from", "", "input format: lammps or json (detected by the extension by default)")
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return usageErrorf("expected one input file")
+	}
+	format, err := detectFormat(*from, files[0], formatLammps)
+	if err != nil {
+		return err
+	}
+	var lammpsStruct *structs.LammpsStruct
+	if format == formatJSON {
+		// readStruct rejects the inconsistent JSON, so it is decoded without the checks
+		var content []byte
+		if content, err = readFile(files[0]); err == nil {
+			lammpsStruct, err = deserialize.DecodeJSON(content, files[0])
+		}
+	} else {
+		lammpsStruct, err = readStruct(files[0], format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(*units) == 0 {
+		*units = lammpsStruct.Units
+	}
+	options := structs.DefaultValidateOptions(*units)
+	if *minBond >= 0 {
+		options.MinBondLength = *minBond
+	}
+	if *maxBond >= 0 {
+		options.MaxBondLength = *maxBond
+	}
+	findings := lammpsStruct.Validate(options)
+	errorsCount := 0
+	for _, finding := range findings {
+		if finding.Severity == structs.SEVERITY_ERROR {
+			errorsCount++
+		}
+	}
+	warningsCount := len(findings) - errorsCount
+
+	err = writeReport(*output, *asJSON, findings, func(writer io.Writer) error {
+		for _, finding := range findings {
+			fmt.Fprintln(writer, finding.String())
+		}
+		fmt.Fprintf(writer, "%d errors, %d warnings\n", errorsCount, warningsCount)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if errorsCount > 0 || (*strict && warningsCount > 0) {
+		return fmt.Errorf("%d errors, %d warnings", errorsCount, warningsCount)
+	}
+	return nil
+}